@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/users"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/utils"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Handler struct for authentication handlers with their dependencies.
+type Handler struct {
+	db            *database.Queries
+	authenticator *auth.Authenticator
+}
+
+// NewHandler func for creating authentication handlers.
+func NewHandler(db *database.Queries, authenticator *auth.Authenticator) *Handler {
+	return &Handler{db: db, authenticator: authenticator}
+}
+
+// UserLookup func for creating the lookup of the current role of token subjects,
+// which rejects tokens of deleted and blocked users.
+func UserLookup(db *database.Queries) auth.UserLookup {
+	return func(id uuid.UUID) (string, error) {
+		// Checking, if user still exists and is active.
+		user, err := db.GetUser(id)
+		if errors.Is(err, models.ErrNotFound) || (err == nil && user.UserStatus != 1) {
+			return "", auth.ErrInvalidToken
+		}
+		if err != nil {
+			return "", err
+		}
+		return user.UserRole, nil
+	}
+}
+
+// refreshRequest struct to describe the body of a token refresh.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Login method for issuing tokens by email and password.
+// @Description Issue access and refresh tokens by user credentials.
+// @Summary log in
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param email body string true "Email"
+// @Param password body string true "Password"
+// @Success 200 {object} auth.TokenPair
+// @Router /v1/auth/login [post]
+// @Router /v2/auth/login [post]
+func (h *Handler) Login(c *fiber.Ctx) error {
+	// Create new Credentials struct
+	credentials := &users.Credentials{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(credentials); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Validate credentials fields.
+	if err := utils.NewValidator().Struct(credentials); err != nil {
+		// Return, if some fields are not valid.
+		return problem.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Get user by email, an unknown email is checked against an empty hash
+	// to answer in the same time as a wrong password.
+	user, err := h.db.GetUserByEmail(credentials.Email)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+	if !auth.CheckPassword(user.PasswordHash, credentials.Password) || user.UserStatus != 1 {
+		// Return status 401 and login error.
+		return problem.New(fiber.StatusUnauthorized, "wrong email or password")
+	}
+
+	return h.issueTokens(c, user)
+}
+
+// Refresh method for issuing new tokens by a refresh token.
+// Refresh tokens are stateless: they are not rotated or revoked on use and
+// stay valid until they expire, revocation is out of scope of this API.
+// @Description Issue new access and refresh tokens by a refresh token. The used refresh token is not revoked and stays valid until it expires.
+// @Summary refresh tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param refresh_token body string true "Refresh token"
+// @Success 200 {object} auth.TokenPair
+// @Router /v1/auth/refresh [post]
+// @Router /v2/auth/refresh [post]
+func (h *Handler) Refresh(c *fiber.Ctx) error {
+	// Create new refresh request struct
+	request := &refreshRequest{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(request); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Validate refresh token.
+	claims, err := h.authenticator.Parse(request.RefreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		// Return status 401 and token error.
+		return problem.New(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Checking, if user still exists and is active.
+	userID, err := claims.UserID()
+	if err == nil {
+		var user users.User
+		if user, err = h.db.GetUser(userID); err == nil && user.UserStatus == 1 {
+			return h.issueTokens(c, user)
+		}
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			// Return error, the error handler maps it to its status.
+			return err
+		}
+	}
+
+	// Return status 401 and token error.
+	return problem.New(fiber.StatusUnauthorized, auth.ErrInvalidToken.Error())
+}
+
+// issueTokens method for answering with a new token pair for the user.
+func (h *Handler) issueTokens(c *fiber.Ctx, user users.User) error {
+	tokens, err := h.authenticator.Issue(user.ID, user.UserRole)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":  false,
+		"msg":    nil,
+		"tokens": tokens,
+	})
+}
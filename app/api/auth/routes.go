@@ -0,0 +1,8 @@
+package auth
+
+import "github.com/gofiber/fiber/v2"
+
+func Routes(route fiber.Router, h *Handler) {
+	route.Post("/auth/login", h.Login)
+	route.Post("/auth/refresh", h.Refresh)
+}
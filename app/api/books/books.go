@@ -1,55 +1,190 @@
 package books
 
 import (
+	"errors"
+	"fiber-api-example/app/models"
 	"fiber-api-example/app/models/books"
 	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/platform/events"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
 	"fiber-api-example/app/utils"
+	"fiber-api-example/app/utils/problem"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"strconv"
 	"time"
 )
 
-// GetBooks func gets all exists books.
-// @Description Get all exists books.
-// @Summary get all exists books
+// Handler struct for book handlers with their dependencies.
+type Handler struct {
+	db     *database.Queries
+	stream *events.Broadcaster
+	blobs  storage.BlobStore
+
+	// v1 is set on handlers of API v1, which write books in their v1 shape.
+	v1 bool
+}
+
+// NewHandler func for creating book handlers using the given database,
+// the broadcaster of book changes and the store of covers.
+func NewHandler(db *database.Queries, stream *events.Broadcaster, blobs storage.BlobStore) *Handler {
+	return &Handler{db: db, stream: stream, blobs: blobs}
+}
+
+// GetBooks method gets a page of books matching the given filters.
+// @Description Get a page of books with filters and sorting.
+// @Summary get books
 // @Tags Books
 // @Accept json
 // @Produce json
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of books to skip"
+// @Param user_id query string false "Owner ID"
+// @Param author query string false "Author (case-insensitive exact match)"
+// @Param title query string false "Title substring"
+// @Param book_status query string false "Book status" Enums(draft, active, archived, withdrawn)
+// @Param rating_min query integer false "Minimal rating"
+// @Param rating_max query integer false "Maximal rating"
+// @Param created_from query string false "Created at or after (RFC 3339)"
+// @Param created_to query string false "Created at or before (RFC 3339)"
+// @Param updated_from query string false "Updated at or after (RFC 3339)"
+// @Param updated_to query string false "Updated at or before (RFC 3339)"
+// @Param sort query string false "Sort fields, e.g. -created_at,title"
+// @Param cursor query string false "Keyset pagination cursor, empty for the first page"
 // @Success 200 {array} books.Book
 // @Router /v1/books [get]
-func GetBooks(c *fiber.Ctx) error {
-	// Create database connection.
-	db, err := database.Connection()
+// @Router /v2/books [get]
+func (h *Handler) GetBooks(c *fiber.Ctx) error {
+	// Read pagination, filter and sort params.
+	params, err := parseListParams(c)
 	if err != nil {
-		// Return status 500 and database connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, err.Error())
 	}
 
-	// Get all books.
-	books, err := db.GetBooks()
+	return h.listBooks(c, params)
+}
+
+// GetUserBooks method gets a page of books owned by the given user.
+// @Description Get a page of user's books with filters and sorting.
+// @Summary get user's books
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of books to skip"
+// @Param sort query string false "Sort fields, e.g. -created_at,title"
+// @Param cursor query string false "Keyset pagination cursor, empty for the first page"
+// @Success 200 {array} books.Book
+// @Router /v1/users/{id}/books [get]
+// @Router /v2/users/{id}/books [get]
+func (h *Handler) GetUserBooks(c *fiber.Ctx) error {
+	// Catch user ID from URL.
+	userID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		// Return, if books not found.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true,
-			"msg":   "books were not found",
-			"count": 0,
-			"books": nil,
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "user ID must be a UUID")
+	}
+
+	// Checking, if user with given ID is exists.
+	if _, err := h.db.GetUser(userID); err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "user with the given ID is not found")
+		}
+		return err
+	}
+
+	// Read pagination, filter and sort params.
+	params, err := parseListParams(c)
+	if err != nil {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, err.Error())
+	}
+	params.Filter.UserID = &userID
+
+	return h.listBooks(c, params)
+}
+
+// listBooks method gets a page of books by offset or cursor.
+func (h *Handler) listBooks(c *fiber.Ctx, params books.BookListParams) error {
+	// Get a page of books by cursor, if keyset pagination is requested.
+	if isCursorMode(c) {
+		return h.getBooksByCursor(c, params)
+	}
+
+	// Get a page of books.
+	books, total, err := h.db.GetBooks(params)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Build links to the neighbour pages.
+	var next, prev interface{}
+	if params.Offset+len(books) < total {
+		next = pageLink(c, "offset", strconv.Itoa(params.Offset+params.Limit))
+	}
+	if params.Offset > 0 {
+		offset := params.Offset - params.Limit
+		if offset < 0 {
+			offset = 0
+		}
+		prev = pageLink(c, "offset", strconv.Itoa(offset))
 	}
 
 	// Return status 200 OK.
 	return c.JSON(fiber.Map{
-		"error": false,
-		"msg":   nil,
-		"count": len(books),
-		"books": books,
+		"error":  false,
+		"msg":    nil,
+		"count":  len(books),
+		"total":  total,
+		"limit":  params.Limit,
+		"offset": params.Offset,
+		"next":   next,
+		"prev":   prev,
+		"books":  h.presentBooks(books),
+	})
+}
+
+// getBooksByCursor method gets a page of books using keyset pagination.
+func (h *Handler) getBooksByCursor(c *fiber.Ctx, params books.BookListParams) error {
+	// Decode cursor of the requested page.
+	cursor, err := parseCursor(c, &params)
+	if err != nil {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Get books after the cursor.
+	books, nextCursor, err := h.db.GetBooksAfter(params, cursor)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Build cursor and link to the next page.
+	var next, encodedCursor interface{}
+	if nextCursor != nil {
+		encoded := nextCursor.Encode()
+		encodedCursor, next = encoded, pageLink(c, "cursor", encoded)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"msg":         nil,
+		"count":       len(books),
+		"limit":       params.Limit,
+		"next_cursor": encodedCursor,
+		"next":        next,
+		"books":       h.presentBooks(books),
 	})
 }
 
-// GetBook func gets book by given ID or 404 error.
+// GetBook method gets book by given ID or 404 error.
 // @Description Get book by given ID.
 // @Summary get book by given ID
 // @Tags Book
@@ -57,47 +192,36 @@ func GetBooks(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Book ID"
 // @Success 200 {object} books.Book
-// @Router /v1/book/{id} [get]
-func GetBook(c *fiber.Ctx) error {
+// @Header 200 {string} ETag "Entity tag of the book version"
+// @Router /v1/books/{id} [get]
+// @Router /v2/books/{id} [get]
+func (h *Handler) GetBook(c *fiber.Ctx) error {
 	// Catch book ID from URL.
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
-	}
-
-	// Create database connection.
-	db, err := database.Connection()
-	if err != nil {
-		// Return status 500 and database connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
 	}
 
 	// Get book by ID.
-	book, err := db.GetBook(id)
+	book, err := h.db.GetBook(id)
 	if err != nil {
-		// Return, if book not found.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true,
-			"msg":   "book with the given ID is not found",
-			"book":  nil,
-		})
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with the given ID is not found")
+		}
+		return err
 	}
 
-	// Return status 200 OK.
+	// Return status 200 OK with the version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(&book))
 	return c.JSON(fiber.Map{
 		"error": false,
 		"msg":   nil,
-		"book":  book,
+		"book":  h.presentBook(&book),
 	})
 }
 
-// NewBook func for creates a new book.
+// NewBook method for creates a new book.
 // @Description Create a new book.
 // @Summary create a new book
 // @Tags Book
@@ -105,11 +229,14 @@ func GetBook(c *fiber.Ctx) error {
 // @Produce json
 // @Param title body string true "Title"
 // @Param author body string true "Author"
+// @Param book_status body string false "Book status, draft if not given" Enums(draft, active)
 // @Param book_attrs body books.BookAttrs true "Book attributes"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
 // @Success 200 {object} books.Book
 // @Security ApiKeyAuth
-// @Router /v1/book [post]
-func NewBook(c *fiber.Ctx) error {
+// @Router /v1/books [post]
+// @Router /v2/books [post]
+func (h *Handler) NewBook(c *fiber.Ctx) error {
 
 	// Create new Book struct
 	book := &books.Book{}
@@ -117,152 +244,245 @@ func NewBook(c *fiber.Ctx) error {
 	// Check, if received JSON data is valid.
 	if err := c.BodyParser(book); err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
 	}
 
-	// Create database connection.
-	db, err := database.Connection()
-	if err != nil {
-		// Return status 500 and database connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
-	}
-
-	// Create a new validator for a Book model.
-	validate := utils.NewValidator()
+	// The authenticated caller becomes the owner of the book.
+	principal, _ := middleware.Principal(c)
 
 	// Set initialized default data for book:
 	book.ID = uuid.New()
 	book.CreatedAt = time.Now()
-	book.BookStatus = 1 // 0 == draft, 1 == active
+	book.UpdatedAt = book.CreatedAt
+	book.Version = 1
+	book.UserID = principal.UserID
+	book.StatusChangedAt = &book.CreatedAt
 
 	// Validate book fields.
-	if err := validate.Struct(book); err != nil {
+	if err := ValidateNewBook(book); err != nil {
 		// Return, if some fields are not valid.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   utils.ValidatorErrors(err),
-		})
+		return err
 	}
 
-	// Delete book by given ID.
-	if err := db.CreateBook(book); err != nil {
-		// Return status 500 and error message.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+	// Checking, if owner of the book is exists.
+	if _, err := h.db.GetUser(book.UserID); err != nil {
+		// Return status 401, if the token belongs to a deleted user, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusUnauthorized, "user of the token is not found")
+		}
+		return err
 	}
 
-	// Return status 200 OK.
+	// Create book.
+	if err := h.db.CreateBook(book, principal.UserID); err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK with the version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(book))
 	return c.JSON(fiber.Map{
 		"error": false,
 		"msg":   nil,
-		"book":  book,
+		"book":  h.presentBook(book),
 	})
 }
 
-// UpdateBook func for updates book by given ID.
+// UpdateBook method for updates book by given ID.
 // @Description Update book.
 // @Summary update book
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Param id path string true "Ignored, the book ID is read from the body"
 // @Param id body string true "Book ID"
 // @Param title body string true "Title"
 // @Param author body string true "Author"
-// @Param book_status body integer true "Book status"
+// @Param book_status body string false "Book status, kept if not given, changes must follow the allowed transitions" Enums(draft, active, archived, withdrawn)
 // @Param book_attrs body books.BookAttrs true "Book attributes"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
 // @Success 201 {string} status "ok"
 // @Security ApiKeyAuth
-// @Router /v1/book [put]
-func UpdateBook(c *fiber.Ctx) error {
-	// Create new Book struct
-	book := &books.Book{}
+// @Router /v1/books/{id} [put]
+func (h *Handler) UpdateBook(c *fiber.Ctx) error {
+	// Create new Book struct, the status is kept, if not given.
+	book := &books.Book{BookStatus: statusUnset}
 
 	// Check, if received JSON data is valid.
 	if err := c.BodyParser(book); err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
 	}
 
-	// Create database connection.
-	db, err := database.Connection()
+	return h.updateBook(c, book.ID, book)
+}
+
+// UpdateBookV2 method for updates book by the ID given in the URL.
+// @Description Update book. The ID in the body is ignored.
+// @Summary update book
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param title body string true "Title"
+// @Param author body string true "Author"
+// @Param book_status body string false "Book status, kept if not given, changes must follow the allowed transitions" Enums(draft, active, archived, withdrawn)
+// @Param book_attrs body books.BookAttrs true "Book attributes"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 201 {string} status "ok"
+// @Header 201 {string} ETag "Entity tag of the book version"
+// @Security ApiKeyAuth
+// @Router /v2/books/{id} [put]
+func (h *Handler) UpdateBookV2(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		// Return status 500 and database connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
 	}
 
+	// Create new Book struct, the status is kept, if not given.
+	book := &books.Book{BookStatus: statusUnset}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(book); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	return h.updateBook(c, id, book)
+}
+
+// updateBook method for replacing book by given ID with the received book.
+func (h *Handler) updateBook(c *fiber.Ctx, id uuid.UUID, book *books.Book) error {
 	// Checking, if book with given ID is exists.
-	foundedBook, err := db.GetBook(book.ID)
+	foundedBook, err := h.db.GetBook(id)
 	if err != nil {
-		// Return status 404 and book not found error.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true,
-			"msg":   "book with this ID not found",
-		})
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
 	}
 
-	// Set initialized default data for book:
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkIfMatch(c, &foundedBook)
+	if err != nil {
+		// Return status 412 or 428 and precondition error.
+		return err
+	}
+
+	// Set initialized default data for book, the owner can not be changed:
+	book.ID = foundedBook.ID
+	book.UserID = foundedBook.UserID
 	book.UpdatedAt = time.Now()
+	book.Version = version
+
+	// Keep the status of the book, if not given, like the GraphQL and gRPC APIs.
+	if book.BookStatus == statusUnset {
+		book.BookStatus = foundedBook.BookStatus
+	}
+
+	// Validate status change and book fields.
+	if err := ValidateBookUpdate(book, &foundedBook); err != nil {
+		// Return status 409 or 400 and error message.
+		return err
+	}
+
+	// Update book by given ID.
+	if err := h.db.UpdateBook(foundedBook.ID, book, principal.UserID); err != nil {
+		// Return status 412, if book was changed meanwhile.
+		if errors.Is(err, books.ErrVersionConflict) {
+			return problem.New(fiber.StatusPreconditionFailed, err.Error())
+		}
+
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Delete the cover, if the picture no longer points to it.
+	DeleteReplacedCover(c.UserContext(), h.blobs, &foundedBook, book)
+
+	// Return status 201 with the new version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(book))
+	return c.SendStatus(fiber.StatusCreated)
+}
 
+// ValidateNewBook func for validating a book to be created,
+// new books are drafts, if no status is given. It returns a validation
+// problem listing the invalid fields.
+func ValidateNewBook(book *books.Book) error {
 	// Create a new validator for a Book model.
 	validate := utils.NewValidator()
 
 	// Validate book fields.
 	if err := validate.Struct(book); err != nil {
-		// Return, if some fields are not valid.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   utils.ValidatorErrors(err),
-		})
+		return problem.Validation(utils.ValidatorErrors(err))
+	}
+	if !isInitialStatus(book.BookStatus) {
+		// The book would skip its lifecycle.
+		return problem.Validation(map[string]string{"book_status": "new books must be " + initialStatusNames()})
 	}
 
-	// Update book by given ID.
-	if err := db.UpdateBook(foundedBook.ID, book); err != nil {
-		// Return status 500 and error message.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+	return nil
+}
+
+// ValidateBookUpdate func for validating a book replacing the stored one.
+// The status of the book is changed from the stored one to the requested one first,
+// it returns a 409 problem, if no transition leads to it, or a validation problem
+// listing the invalid fields.
+func ValidateBookUpdate(book, foundedBook *books.Book) error {
+	// Checking, if the book may change to the requested status.
+	if err := changeStatus(book, foundedBook); err != nil {
+		return err
 	}
 
-	// Return status 201.
-	return c.SendStatus(fiber.StatusCreated)
+	// Checking, if the picture points to no other cover upload than the current one,
+	// covers are only uploaded by the cover endpoint.
+	if _, ok := coverUpload(book); ok && book.BookAttrs.Picture != foundedBook.BookAttrs.Picture {
+		return problem.Validation(map[string]string{"picture": "picture must be the current cover, covers are uploaded by PUT /books/{id}/cover"})
+	}
+
+	// Create a new validator for a Book model.
+	validate := utils.NewValidator()
+
+	// Validate book fields.
+	if err := validate.Struct(book); err != nil {
+		return problem.Validation(utils.ValidatorErrors(err))
+	}
+
+	return nil
 }
 
-// DeleteBook func for deletes book by given ID.
-// @Description Delete book by given ID.
+// DeleteBook method for moves book by given ID to the trash.
+// @Description Delete book by given ID. It is kept in the trash until purged.
 // @Summary delete book by given ID
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Param id path string true "Ignored, the book ID is read from the body"
 // @Param id body string true "Book ID"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
 // @Success 204 {string} status "ok"
 // @Security ApiKeyAuth
-// @Router /v1/book [delete]
-func DeleteBook(c *fiber.Ctx) error {
+// @Router /v1/books/{id} [delete]
+func (h *Handler) DeleteBook(c *fiber.Ctx) error {
 	// Create new Book struct
 	book := &books.Book{}
 
 	// Check, if received JSON data is valid.
 	if err := c.BodyParser(book); err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
 	}
 
 	// Create a new validator for a Book model.
@@ -271,39 +491,69 @@ func DeleteBook(c *fiber.Ctx) error {
 	// Validate only one book field ID.
 	if err := validate.StructPartial(book, "id"); err != nil {
 		// Return, if some fields are not valid.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   utils.ValidatorErrors(err),
-		})
+		return problem.Validation(utils.ValidatorErrors(err))
 	}
 
-	// Create database connection.
-	db, err := database.Connection()
+	return h.deleteBook(c, book.ID)
+}
+
+// DeleteBookV2 method for moves book by the ID given in the URL to the trash.
+// @Description Delete book by given ID. It is kept in the trash until purged.
+// @Summary delete book by given ID
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v2/books/{id} [delete]
+func (h *Handler) DeleteBookV2(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		// Return status 500 and database connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
 	}
 
+	return h.deleteBook(c, id)
+}
+
+// deleteBook method for moving book by given ID to the trash.
+func (h *Handler) deleteBook(c *fiber.Ctx, id uuid.UUID) error {
 	// Checking, if book with given ID is exists.
-	foundedBook, err := db.GetBook(book.ID)
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkIfMatch(c, &foundedBook)
 	if err != nil {
-		// Return status 404 and book not found error.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": true,
-			"msg":   "book with this ID not found",
-		})
+		// Return status 412 or 428 and precondition error.
+		return err
 	}
 
 	// Delete book by given ID.
-	if err := db.DeleteBook(foundedBook.ID); err != nil {
-		// Return status 500 and error message.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+	if err := h.db.DeleteBook(foundedBook.ID, version, principal.UserID); err != nil {
+		// Return status 412, if book was changed meanwhile.
+		if errors.Is(err, books.ErrVersionConflict) {
+			return problem.New(fiber.StatusPreconditionFailed, err.Error())
+		}
+
+		// Return error, the error handler maps it to its status.
+		return err
 	}
 
 	// Return status 204 no content.
@@ -0,0 +1,329 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/images"
+	"fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"image"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UploadBookCover method for replacing the cover of book by given ID.
+// @Description Upload a JPEG, PNG or WebP cover of a book. Thumbnails are generated in the configured widths and the picture of the book points to the cover.
+// @Summary upload book cover
+// @Tags Book
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param cover formData file true "Cover image"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 200 {object} books.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/cover [put]
+// @Router /v2/books/{id}/cover [put]
+func (h *Handler) UploadBookCover(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is exists.
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkIfMatch(c, &foundedBook)
+	if err != nil {
+		// Return status 412 or 428 and precondition error.
+		return err
+	}
+
+	// Read the uploaded image.
+	data, err := readCover(c)
+	if err != nil {
+		return err
+	}
+
+	// Checking, if the image is a supported format by its magic bytes.
+	if images.Sniff(data) == "" {
+		// Return status 415 and supported formats.
+		return problem.New(fiber.StatusUnsupportedMediaType, images.ErrUnsupported.Error())
+	}
+	img, contentType, err := images.Decode(data, viper.GetInt("COVER_MAX_PIXELS"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "cover is not a valid image")
+	}
+
+	// Store the original and its thumbnails under keys of this upload
+	// before the book points to them, so concurrent uploads do not
+	// overwrite the cover the book points to.
+	ctx := c.UserContext()
+	upload := uuid.New()
+	if err := h.putCover(ctx, id, upload, data, img, contentType); err != nil {
+		_ = h.blobs.DeletePrefix(ctx, coverPrefix(id, upload))
+		return err
+	}
+
+	// Point the picture of the book to the cover.
+	book := foundedBook
+	book.BookAttrs.Picture = coverURL(id, upload)
+	book.UpdatedAt = time.Now()
+	book.Version = version
+
+	// Update book by given ID.
+	if err := h.db.UpdateBook(foundedBook.ID, &book, principal.UserID); err != nil {
+		// Delete the cover, the book does not point to it.
+		_ = h.blobs.DeletePrefix(ctx, coverPrefix(id, upload))
+
+		// Return status 412, if book was changed meanwhile.
+		if errors.Is(err, books.ErrVersionConflict) {
+			return problem.New(fiber.StatusPreconditionFailed, err.Error())
+		}
+
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Delete the replaced cover.
+	DeleteReplacedCover(ctx, h.blobs, &foundedBook, &book)
+
+	// Return status 200 OK with the new version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(&book))
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"book":  h.presentBook(&book),
+	})
+}
+
+// GetBookCover method for serving the cover of book by given ID.
+// @Description Get the cover of a book or one of its thumbnails. The picture of books with an uploaded cover points here.
+// @Summary get book cover
+// @Tags Book
+// @Produce image/jpeg,image/png,image/webp
+// @Param id path string true "Book ID"
+// @Param width query integer false "Thumbnail width, one of the configured widths, the original if not given"
+// @Param upload query string false "Ignored, names the cover upload in the picture of the book, so a replaced cover gets a new URL"
+// @Success 200 {file} file
+// @Router /v1/books/{id}/cover [get]
+// @Router /v2/books/{id}/cover [get]
+func (h *Handler) GetBookCover(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Read the requested thumbnail width.
+	width := 0
+	if c.Query("width") != "" {
+		width, err = strconv.Atoi(c.Query("width"))
+		if err != nil || !isCoverWidth(width) {
+			// Return status 400 and the configured widths.
+			return problem.New(fiber.StatusBadRequest, fmt.Sprintf("width must be one of %v", coverWidths()))
+		}
+	}
+
+	// Checking, if book with given ID is exists.
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with the given ID is not found")
+		}
+		return err
+	}
+
+	// Checking, if the picture of the book points to an uploaded cover.
+	upload, ok := coverUpload(&foundedBook)
+	if !ok {
+		return problem.New(fiber.StatusNotFound, "book has no cover")
+	}
+
+	// Open the stored cover.
+	blob, err := h.blobs.Open(c.UserContext(), coverKey(id, upload, width))
+	if err != nil {
+		// Return status 404, if no cover was uploaded, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book has no cover")
+		}
+		return err
+	}
+
+	// Covers are served under the URL of the book, so clients revalidate them.
+	etag := fmt.Sprintf(`"%x-%x"`, blob.ModTime.UnixNano(), blob.Size)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, blob.ModTime.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	if matchesETag(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		_ = blob.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	// Detect the content type by the magic bytes.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(blob, head)
+	if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		_, err = blob.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = blob.Close()
+		return err
+	}
+	c.Set(fiber.HeaderContentType, images.Sniff(head[:n]))
+
+	// Send the cover, the stream is closed after sending.
+	return c.SendStream(blob, int(blob.Size))
+}
+
+// readCover func for reading the uploaded cover of the multipart form.
+// It returns a 400 problem, if no cover is uploaded, or a 413 problem,
+// if it is larger than COVER_MAX_SIZE.
+func readCover(c *fiber.Ctx) ([]byte, error) {
+	file, err := c.FormFile("cover")
+	if err != nil {
+		return nil, problem.Wrap(fiber.StatusBadRequest, err, "cover must be uploaded as multipart form file")
+	}
+
+	maxSize := viper.GetInt64("COVER_MAX_SIZE")
+	if file.Size > maxSize {
+		return nil, problem.New(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("cover must not be larger than %d bytes", maxSize))
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(io.LimitReader(f, maxSize))
+}
+
+// putCover method for storing the uploaded cover of a book and its thumbnails
+// under the keys of the given upload.
+func (h *Handler) putCover(ctx context.Context, id, upload uuid.UUID, data []byte, img image.Image, contentType string) error {
+	if err := h.blobs.Put(ctx, coverKey(id, upload, 0), bytes.NewReader(data)); err != nil {
+		return err
+	}
+	for _, width := range coverWidths() {
+		var thumbnail bytes.Buffer
+		if err := images.Encode(&thumbnail, images.Thumbnail(img, width), contentType); err != nil {
+			return err
+		}
+		if err := h.blobs.Put(ctx, coverKey(id, upload, width), &thumbnail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coverPrefix func for getting the blob key prefix of a cover upload of a book.
+func coverPrefix(id, upload uuid.UUID) string {
+	return storage.CoversPrefix + "/" + id.String() + "/" + upload.String()
+}
+
+// coverKey func for getting the blob key of a cover upload of a book,
+// or of its thumbnail of the given width, if not zero.
+func coverKey(id, upload uuid.UUID, width int) string {
+	if width == 0 {
+		return coverPrefix(id, upload) + "/original"
+	}
+	return coverPrefix(id, upload) + "/" + strconv.Itoa(width)
+}
+
+// coverURL func for getting the URL of a cover upload of a book. It is not
+// versioned by the API, so it stays valid, when the default API version changes,
+// and names the upload, so a replaced cover gets a new URL.
+func coverURL(id, upload uuid.UUID) string {
+	return "/api/books/" + id.String() + "/cover?upload=" + upload.String()
+}
+
+// coverUpload func for getting the cover upload the picture of a book points to.
+// It returns false, if the picture is not an uploaded cover of the book.
+func coverUpload(book *books.Book) (uuid.UUID, bool) {
+	prefix := "/api/books/" + book.ID.String() + "/cover?upload="
+	if !strings.HasPrefix(book.BookAttrs.Picture, prefix) {
+		return uuid.Nil, false
+	}
+	upload, err := uuid.Parse(strings.TrimPrefix(book.BookAttrs.Picture, prefix))
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return upload, true
+}
+
+// DeleteReplacedCover func for deleting the uploaded cover of a book after an
+// update, if the picture of the updated book no longer points to it.
+// Leftovers are only logged, they are deleted with the book at the latest.
+func DeleteReplacedCover(ctx context.Context, blobs storage.BlobStore, before, after *books.Book) {
+	previous, ok := coverUpload(before)
+	if !ok || blobs == nil || after.BookAttrs.Picture == before.BookAttrs.Picture {
+		return
+	}
+	if err := blobs.DeletePrefix(ctx, coverPrefix(before.ID, previous)); err != nil {
+		logger.Error("Can't delete replaced cover of book "+before.ID.String()+": ", err)
+	}
+}
+
+// coverWidths func for reading the widths of thumbnails of covers.
+func coverWidths() []int {
+	widths := []int{}
+	for _, s := range strings.Split(viper.GetString("COVER_THUMBNAIL_WIDTHS"), ",") {
+		if width, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && width > 0 {
+			widths = append(widths, width)
+		}
+	}
+	return widths
+}
+
+// isCoverWidth func for checking, if thumbnails of the given width are generated.
+func isCoverWidth(width int) bool {
+	for _, w := range coverWidths() {
+		if w == width {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesETag func for evaluating an If-None-Match header against an entity tag,
+// comparing weakly.
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/"); tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
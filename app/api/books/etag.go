@@ -0,0 +1,42 @@
+package books
+
+import (
+	"strconv"
+	"strings"
+
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// bookETag func for building the entity tag of the stored book version.
+func bookETag(b *books.Book) string {
+	return `"` + strconv.Itoa(b.Version) + `"`
+}
+
+// checkIfMatch func for evaluating the If-Match header of a write request
+// against the stored book. It returns the version the write must be based on
+// or a 412 or 428 problem, if the precondition is not met.
+func checkIfMatch(c *fiber.Ctx, b *books.Book) (int, error) {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+
+	// Without If-Match the write is based on the version just read,
+	// unless clients are required to send it.
+	if ifMatch == "" {
+		if viper.GetBool("API_REQUIRE_IF_MATCH") {
+			return 0, problem.New(fiber.StatusPreconditionRequired, "If-Match header is required")
+		}
+		return b.Version, nil
+	}
+
+	// Compare entity tags strongly, weak tags never match.
+	etag := bookETag(b)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag {
+			return b.Version, nil
+		}
+	}
+
+	return 0, problem.New(fiber.StatusPreconditionFailed, books.ErrVersionConflict.Error())
+}
@@ -0,0 +1,445 @@
+package books
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/files"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/problem"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// GetBookFiles method gets the e-book files of book by given ID.
+// @Description Get the e-book files of a book. Files of drafts and withdrawn books are only listed for the owner and admins.
+// @Summary get book files
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Success 200 {array} files.File
+// @Router /v1/books/{id}/files [get]
+// @Router /v2/books/{id}/files [get]
+func (h *Handler) GetBookFiles(c *fiber.Ctx) error {
+	// Catch book ID from URL and check access.
+	book, err := h.downloadableBook(c)
+	if err != nil {
+		return err
+	}
+
+	// Get all files of the book.
+	list, err := h.db.GetBookFiles(book.ID)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"count": len(list),
+		"files": list,
+	})
+}
+
+// UploadBookFile method for attaching an e-book file to book by given ID.
+// @Description Upload a PDF or EPUB file of a book. Its size and SHA-256 checksum are stored with it.
+// @Summary upload book file
+// @Tags Book
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param file formData file true "PDF or EPUB file"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 200 {object} files.File
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/files [post]
+// @Router /v2/books/{id}/files [post]
+func (h *Handler) UploadBookFile(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is exists.
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if a file is uploaded within the size limit.
+	upload, err := c.FormFile("file")
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "file must be uploaded as multipart form file")
+	}
+	maxSize := viper.GetInt64("FILE_MAX_SIZE")
+	if upload.Size > maxSize {
+		// Return status 413 and the size limit.
+		return problem.New(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("file must not be larger than %d bytes", maxSize))
+	}
+
+	content, err := upload.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	// Checking, if the file is a PDF or EPUB by its magic bytes.
+	head := make([]byte, 64)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	head = head[:n]
+	format := files.Sniff(head)
+	if format == "" {
+		// Return status 415 and supported formats.
+		return problem.New(fiber.StatusUnsupportedMediaType, "file must be PDF or EPUB")
+	}
+
+	// Set initialized default data for file:
+	file := &files.File{
+		ID:        uuid.New(),
+		BookID:    foundedBook.ID,
+		CreatedAt: time.Now(),
+		UserID:    &principal.UserID,
+		Name:      fileName(upload.Filename, foundedBook.ID, format),
+		Format:    format,
+	}
+
+	// Store the content, computing its checksum and size on the way.
+	hash := sha256.New()
+	size := &byteCounter{}
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), content), io.MultiWriter(hash, size))
+	if err := h.blobs.Put(c.UserContext(), bookFileKey(file), body); err != nil {
+		return err
+	}
+	file.Size = size.n
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	// Create file, removing the stored content, if it fails.
+	if err := h.db.CreateBookFile(file); err != nil {
+		_ = h.blobs.Delete(c.UserContext(), bookFileKey(file))
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"file":  file,
+	})
+}
+
+// DownloadBookFile method for downloading an e-book file of book by given ID.
+// @Description Download an e-book file of a book. Single byte ranges are supported to resume downloads, the entity tag is the SHA-256 checksum. Files of drafts and withdrawn books are only served to the owner and admins.
+// @Summary download book file
+// @Tags Book
+// @Produce application/pdf,application/epub+zip
+// @Param id path string true "Book ID"
+// @Param file path string true "File ID"
+// @Param Range header string false "Byte range, e.g. bytes=1024-"
+// @Param If-Range header string false "Entity tag or last modification time the range is based on"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Router /v1/books/{id}/files/{file} [get]
+// @Router /v2/books/{id}/files/{file} [get]
+func (h *Handler) DownloadBookFile(c *fiber.Ctx) error {
+	// Catch book ID from URL and check access.
+	book, err := h.downloadableBook(c)
+	if err != nil {
+		return err
+	}
+
+	// Checking, if file with given ID is exists.
+	file, err := h.bookFile(c, &book)
+	if err != nil {
+		return err
+	}
+
+	// Open the stored content.
+	blob, err := h.blobs.Open(c.UserContext(), bookFileKey(&file))
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Files are never changed, the checksum identifies the content.
+	etag := `"` + file.SHA256 + `"`
+	lastModified := file.CreatedAt.UTC().Format(http.TimeFormat)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, lastModified)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	if matchesETag(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		_ = blob.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	// Send the whole content, unless a range of the current content is requested.
+	start, length, ok, err := parseRange(c.Get(fiber.HeaderRange), blob.Size)
+	if !ok || !ifRangeMatches(c.Get(fiber.HeaderIfRange), etag, lastModified) {
+		setFileHeaders(c, &file)
+		return c.SendStream(blob, int(blob.Size))
+	}
+	if err != nil {
+		_ = blob.Close()
+		// Return status 416 and the size of the content.
+		c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(blob.Size, 10))
+		return problem.New(fiber.StatusRequestedRangeNotSatisfiable, err.Error())
+	}
+
+	// Send the requested range.
+	if _, err := blob.Seek(start, io.SeekStart); err != nil {
+		_ = blob.Close()
+		return err
+	}
+	setFileHeaders(c, &file)
+	c.Status(fiber.StatusPartialContent)
+	c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, blob.Size))
+	return c.SendStream(struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(blob, length), blob}, int(length))
+}
+
+// DeleteBookFile method for deleting an e-book file of book by given ID.
+// @Description Delete an e-book file of a book.
+// @Summary delete book file
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param file path string true "File ID"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/files/{file} [delete]
+// @Router /v2/books/{id}/files/{file} [delete]
+func (h *Handler) DeleteBookFile(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is exists.
+	book, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &book) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if file with given ID is exists.
+	file, err := h.bookFile(c, &book)
+	if err != nil {
+		return err
+	}
+
+	// Delete file, then its content.
+	if err := h.db.DeleteBookFile(book.ID, file.ID); err != nil {
+		// Return status 404, if deleted meanwhile, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "file with this ID not found")
+		}
+		return err
+	}
+	if err := h.blobs.Delete(c.UserContext(), bookFileKey(&file)); err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// downloadableBook method for getting the book of the URL,
+// whose files the caller may download.
+func (h *Handler) downloadableBook(c *fiber.Ctx) (books.Book, error) {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return books.Book{}, problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is exists.
+	book, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return book, problem.New(fiber.StatusNotFound, "book with the given ID is not found")
+		}
+		return book, err
+	}
+
+	// Checking, if caller may download the files of the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanDownloadBookFiles(principal, &book) {
+		// Return status 403 and forbidden error.
+		return book, problem.New(fiber.StatusForbidden, "files of draft and withdrawn books may only be downloaded by the owner or an admin")
+	}
+
+	return book, nil
+}
+
+// bookFile method for getting the file of the URL attached to the given book.
+func (h *Handler) bookFile(c *fiber.Ctx, book *books.Book) (files.File, error) {
+	// Catch file ID from URL.
+	id, err := uuid.Parse(c.Params("file"))
+	if err != nil {
+		return files.File{}, problem.Wrap(fiber.StatusBadRequest, err, "file ID must be a UUID")
+	}
+
+	file, err := h.db.GetBookFile(book.ID, id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return file, problem.New(fiber.StatusNotFound, "file with this ID not found")
+		}
+		return file, err
+	}
+
+	return file, nil
+}
+
+// setFileHeaders func for describing the content of a file sent in the response.
+func setFileHeaders(c *fiber.Ctx, f *files.File) {
+	c.Set(fiber.HeaderContentType, f.ContentType())
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": f.Name}); disposition != "" {
+		c.Set(fiber.HeaderContentDisposition, disposition)
+	}
+}
+
+// bookFileKey func for getting the blob key of the content of a file.
+func bookFileKey(f *files.File) string {
+	return storage.FilesPrefix + "/" + f.BookID.String() + "/" + f.ID.String()
+}
+
+// fileName func for getting the name a file is downloaded as from the name
+// of the uploaded file, without directories and at most 255 bytes long.
+func fileName(uploaded string, bookID uuid.UUID, format string) string {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(uploaded, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		name = bookID.String() + "." + format
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// parseRange func for parsing a Range header requesting a single byte range
+// of content of the given size. ok is false, if the header is to be ignored,
+// e.g. for other units, several ranges or invalid syntax, so the whole content
+// is sent. err is set, if the range is not satisfiable.
+func parseRange(header string, size int64) (start, length int64, ok bool, err error) {
+	spec := strings.TrimSpace(header)
+	if !strings.HasPrefix(spec, "bytes=") || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(strings.TrimPrefix(spec, "bytes=")), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+	first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+	// Suffix range of the last bytes, e.g. "bytes=-500".
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false, nil
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, true, errors.New("range is not satisfiable")
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true, nil
+	}
+
+	// Range from the first byte to the last one or to the end, e.g. "bytes=500-999".
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false, nil
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, true, errors.New("range is not satisfiable")
+	}
+
+	return start, end - start + 1, true, nil
+}
+
+// ifRangeMatches func for evaluating an If-Range header, the range is only
+// sent, if the entity tag matches strongly or the time is the last modification.
+func ifRangeMatches(ifRange, etag, lastModified string) bool {
+	ifRange = strings.TrimSpace(ifRange)
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return ifRange == etag
+	}
+	return ifRange == lastModified
+}
+
+// byteCounter struct for counting the bytes written to it.
+type byteCounter struct {
+	n int64
+}
+
+// Write method for counting the written bytes.
+func (b *byteCounter) Write(p []byte) (int, error) {
+	b.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,74 @@
+package books
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		size       int64
+		wantStart  int64
+		wantLength int64
+		wantOK     bool
+		wantErr    bool
+	}{
+		{name: "no header", header: "", size: 100},
+		{name: "first bytes", header: "bytes=0-9", size: 100, wantStart: 0, wantLength: 10, wantOK: true},
+		{name: "middle bytes", header: "bytes=10-19", size: 100, wantStart: 10, wantLength: 10, wantOK: true},
+		{name: "open end", header: "bytes=90-", size: 100, wantStart: 90, wantLength: 10, wantOK: true},
+		{name: "end beyond size", header: "bytes=90-500", size: 100, wantStart: 90, wantLength: 10, wantOK: true},
+		{name: "spaces", header: " bytes= 5 - 6 ", size: 100, wantStart: 5, wantLength: 2, wantOK: true},
+		{name: "suffix", header: "bytes=-10", size: 100, wantStart: 90, wantLength: 10, wantOK: true},
+		{name: "suffix beyond size", header: "bytes=-500", size: 100, wantStart: 0, wantLength: 100, wantOK: true},
+		{name: "start beyond size", header: "bytes=100-", size: 100, wantOK: true, wantErr: true},
+		{name: "empty suffix", header: "bytes=-0", size: 100, wantOK: true, wantErr: true},
+		{name: "suffix of empty content", header: "bytes=-10", size: 0, wantOK: true, wantErr: true},
+		{name: "other unit", header: "items=0-9", size: 100},
+		{name: "several ranges", header: "bytes=0-9,20-29", size: 100},
+		{name: "no dash", header: "bytes=10", size: 100},
+		{name: "end before start", header: "bytes=20-10", size: 100},
+		{name: "negative start", header: "bytes=--10", size: 100},
+		{name: "not a number", header: "bytes=a-b", size: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, length, ok, err := parseRange(tt.header, tt.size)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseRange(%q, %d) ok = %v, err = %v, want ok %v, wantErr %v", tt.header, tt.size, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if start != tt.wantStart || length != tt.wantLength {
+				t.Errorf("parseRange(%q, %d) = %d+%d, want %d+%d", tt.header, tt.size, start, length, tt.wantStart, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestIfRangeMatches(t *testing.T) {
+	const (
+		etag         = `"5d41402abc4b2a76b9719d911017c592"`
+		lastModified = "Thu, 01 Sep 2022 12:00:00 GMT"
+	)
+
+	tests := []struct {
+		name    string
+		ifRange string
+		want    bool
+	}{
+		{name: "no header", ifRange: "", want: true},
+		{name: "same entity tag", ifRange: etag, want: true},
+		{name: "other entity tag", ifRange: `"other"`, want: false},
+		{name: "weak entity tag", ifRange: "W/" + etag, want: false},
+		{name: "last modification", ifRange: lastModified, want: true},
+		{name: "other time", ifRange: "Fri, 02 Sep 2022 12:00:00 GMT", want: false},
+		{name: "spaces", ifRange: " " + etag + " ", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ifRangeMatches(tt.ifRange, etag, lastModified); got != tt.want {
+				t.Errorf("ifRangeMatches(%q) = %v, want %v", tt.ifRange, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package books
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"fiber-api-example/app/models/books"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/valyala/fasthttp"
+)
+
+// parseListParams func for reading pagination, filter and sort options
+// from the query string of a books listing request.
+func parseListParams(c *fiber.Ctx) (books.BookListParams, error) {
+	params := books.BookListParams{
+		Limit: viper.GetInt("API_PAGINATION_DEFAULT_LIMIT"),
+	}
+
+	var err error
+
+	// Pagination.
+	if params.Limit, err = queryInt(c, "limit", params.Limit); err != nil {
+		return params, err
+	}
+	if params.Limit < 1 || params.Limit > viper.GetInt("API_PAGINATION_MAX_LIMIT") {
+		return params, fmt.Errorf("limit must be between 1 and %d", viper.GetInt("API_PAGINATION_MAX_LIMIT"))
+	}
+	if params.Offset, err = queryInt(c, "offset", 0); err != nil {
+		return params, err
+	}
+	if params.Offset < 0 {
+		return params, fmt.Errorf("offset must not be negative")
+	}
+
+	// Filters.
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return params, fmt.Errorf("user_id must be a UUID")
+		}
+		params.Filter.UserID = &id
+	}
+	params.Filter.Author = c.Query("author")
+	params.Filter.Title = c.Query("title")
+	if status := c.Query("book_status"); status != "" {
+		s, err := books.ParseStatus(status)
+		if err != nil {
+			return params, err
+		}
+		params.Filter.BookStatus = &s
+	}
+	if params.Filter.RatingMin, err = queryIntPtr(c, "rating_min"); err != nil {
+		return params, err
+	}
+	if params.Filter.RatingMax, err = queryIntPtr(c, "rating_max"); err != nil {
+		return params, err
+	}
+	if params.Filter.CreatedFrom, err = queryTimePtr(c, "created_from"); err != nil {
+		return params, err
+	}
+	if params.Filter.CreatedTo, err = queryTimePtr(c, "created_to"); err != nil {
+		return params, err
+	}
+	if params.Filter.UpdatedFrom, err = queryTimePtr(c, "updated_from"); err != nil {
+		return params, err
+	}
+	if params.Filter.UpdatedTo, err = queryTimePtr(c, "updated_to"); err != nil {
+		return params, err
+	}
+
+	// Sorting.
+	if params.Sort, err = books.ParseSort(c.Query("sort")); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// isCursorMode func for checking, if keyset pagination is requested.
+// An empty "cursor" parameter requests the first page.
+func isCursorMode(c *fiber.Ctx) bool {
+	return c.Request().URI().QueryArgs().Has("cursor")
+}
+
+// parseCursor func for decoding the cursor of a keyset paginated request
+// and aligning the sort order of params with it.
+func parseCursor(c *fiber.Ctx, params *books.BookListParams) (*books.Cursor, error) {
+	if c.Query("offset") != "" {
+		return nil, fmt.Errorf("offset can not be combined with cursor")
+	}
+
+	if c.Query("cursor") == "" {
+		return nil, nil
+	}
+
+	cursor, err := books.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The sort order is part of the cursor, so it may be omitted on next pages.
+	if c.Query("sort") == "" {
+		params.Sort = cursor.Sort
+	} else if !cursor.MatchesSort(params.Sort) {
+		return nil, fmt.Errorf("cursor does not match the sort order")
+	}
+
+	return cursor, nil
+}
+
+// queryInt func for reading an integer query parameter with a default value.
+func queryInt(c *fiber.Ctx, key string, def int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+
+	return i, nil
+}
+
+// queryIntPtr func for reading an optional integer query parameter.
+func queryIntPtr(c *fiber.Ctx, key string) (*int, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+
+	i, err := queryInt(c, key, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// queryTimePtr func for reading an optional RFC 3339 time query parameter.
+func queryTimePtr(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+	}
+
+	return &t, nil
+}
+
+// pageLink func for building a link to the current listing with
+// the given query parameter replaced.
+func pageLink(c *fiber.Ctx, key, value string) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	c.Request().URI().QueryArgs().CopyTo(args)
+	args.Set(key, value)
+
+	return c.BaseURL() + c.Path() + "?" + args.String()
+}
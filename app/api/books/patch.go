@@ -0,0 +1,153 @@
+package books
+
+import (
+	"encoding/json"
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/problem"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"mime"
+	"time"
+)
+
+// Media types of supported patch documents.
+const (
+	MIMEMergePatch = "application/merge-patch+json" // RFC 7396
+	MIMEJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// PatchBook method for partially updating book by given ID.
+// @Description Patch book with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document.
+// @Summary patch book
+// @Tags Book
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param patch body object true "Patch document"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 200 {object} books.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id} [patch]
+// @Router /v2/books/{id} [patch]
+func (h *Handler) PatchBook(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if patch format is supported.
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if mediaType != MIMEMergePatch && mediaType != MIMEJSONPatch {
+		// Return status 415 and supported formats.
+		c.Set("Accept-Patch", MIMEMergePatch+", "+MIMEJSONPatch)
+		return problem.New(fiber.StatusUnsupportedMediaType, "patch must be "+MIMEMergePatch+" or "+MIMEJSONPatch)
+	}
+
+	// Checking, if book with given ID is exists.
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkIfMatch(c, &foundedBook)
+	if err != nil {
+		// Return status 412 or 428 and precondition error.
+		return err
+	}
+
+	// Apply patch to the stored book.
+	book, err := applyPatch(&foundedBook, mediaType, c.Body())
+	if err != nil {
+		return err
+	}
+
+	// Keep fields, which can not be changed by clients.
+	book.ID = foundedBook.ID
+	book.CreatedAt = foundedBook.CreatedAt
+	book.UserID = foundedBook.UserID
+
+	// Set initialized default data for book:
+	book.UpdatedAt = time.Now()
+	book.Version = version
+
+	// Validate status change and patched book fields.
+	if err := ValidateBookUpdate(book, &foundedBook); err != nil {
+		// Return status 409 or 400 and error message.
+		return err
+	}
+
+	// Update book by given ID.
+	if err := h.db.UpdateBook(foundedBook.ID, book, principal.UserID); err != nil {
+		// Return status 412, if book was changed meanwhile.
+		if errors.Is(err, books.ErrVersionConflict) {
+			return problem.New(fiber.StatusPreconditionFailed, err.Error())
+		}
+
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Delete the cover, if the picture no longer points to it.
+	DeleteReplacedCover(c.UserContext(), h.blobs, &foundedBook, book)
+
+	// Return status 200 OK with the new version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(book))
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"book":  h.presentBook(book),
+	})
+}
+
+// applyPatch func for applying a patch document to a book.
+// It returns a 400 or 422 problem, if the patch can not be applied.
+func applyPatch(book *books.Book, mediaType string, patch []byte) (*books.Book, error) {
+	// Encode book into a JSON document.
+	doc, err := json.Marshal(book)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply patch, nested objects like book_attrs are merged deeply.
+	switch mediaType {
+	case MIMEMergePatch:
+		if doc, err = jsonpatch.MergePatch(doc, patch); err != nil {
+			return nil, problem.Wrap(fiber.StatusBadRequest, err, "merge patch is not a valid JSON document")
+		}
+	case MIMEJSONPatch:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, problem.Wrap(fiber.StatusBadRequest, err, "JSON patch is not a valid list of operations")
+		}
+		if doc, err = operations.Apply(doc); err != nil {
+			return nil, problem.Wrap(fiber.StatusUnprocessableEntity, err, "JSON patch can not be applied to the book")
+		}
+	}
+
+	// Decode patched document into a new book.
+	patched := &books.Book{}
+	if err := json.Unmarshal(doc, patched); err != nil {
+		return nil, problem.Wrap(fiber.StatusUnprocessableEntity, err, "patched document is not a valid book")
+	}
+
+	return patched, nil
+}
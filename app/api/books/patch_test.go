@@ -0,0 +1,362 @@
+package books
+
+import (
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/problem"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func TestApplyPatch(t *testing.T) {
+	book := &books.Book{
+		ID:        uuid.New(),
+		Title:     "Title",
+		Author:    "Author",
+		BookAttrs: books.BookAttrs{Description: "Description", Rating: 5},
+	}
+
+	tests := []struct {
+		name       string
+		mediaType  string
+		patch      string
+		wantStatus int
+		want       func(b *books.Book) bool
+	}{
+		{
+			name:      "merge title",
+			mediaType: MIMEMergePatch,
+			patch:     `{"title":"New title"}`,
+			want: func(b *books.Book) bool {
+				return b.Title == "New title" && b.Author == "Author" && b.BookAttrs.Rating == 5
+			},
+		},
+		{
+			name:      "merge nested attributes",
+			mediaType: MIMEMergePatch,
+			patch:     `{"book_attrs":{"rating":9}}`,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Rating == 9 && b.BookAttrs.Description == "Description"
+			},
+		},
+		{
+			name:      "merge removes attribute",
+			mediaType: MIMEMergePatch,
+			patch:     `{"book_attrs":{"description":null}}`,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Description == "" && b.BookAttrs.Rating == 5
+			},
+		},
+		{
+			name:       "merge invalid JSON",
+			mediaType:  MIMEMergePatch,
+			patch:      `{"title":`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "merge invalid book",
+			mediaType:  MIMEMergePatch,
+			patch:      `{"book_attrs":{"rating":"high"}}`,
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:      "JSON patch replace",
+			mediaType: MIMEJSONPatch,
+			patch:     `[{"op":"replace","path":"/book_attrs/rating","value":1},{"op":"replace","path":"/title","value":"Patched"}]`,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Rating == 1 && b.Title == "Patched" && b.Author == "Author"
+			},
+		},
+		{
+			name:      "JSON patch test",
+			mediaType: MIMEJSONPatch,
+			patch:     `[{"op":"test","path":"/title","value":"Title"},{"op":"remove","path":"/book_attrs/description"}]`,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Description == ""
+			},
+		},
+		{
+			name:       "JSON patch failed test",
+			mediaType:  MIMEJSONPatch,
+			patch:      `[{"op":"test","path":"/title","value":"Other"},{"op":"replace","path":"/title","value":"Patched"}]`,
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:       "JSON patch missing path",
+			mediaType:  MIMEJSONPatch,
+			patch:      `[{"op":"remove","path":"/subtitle"}]`,
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:       "JSON patch not a list",
+			mediaType:  MIMEJSONPatch,
+			patch:      `{"op":"replace","path":"/title","value":"Patched"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch(book, tt.mediaType, []byte(tt.patch))
+			status := 0
+			if err != nil {
+				status = problem.From(err).Status
+			}
+			if status != tt.wantStatus {
+				t.Fatalf("applyPatch() status = %d, err = %v, want status %d", status, err, tt.wantStatus)
+			}
+			if err == nil && !tt.want(got) {
+				t.Errorf("applyPatch() = %+v", got)
+			}
+			if book.Title != "Title" || book.BookAttrs.Description != "Description" {
+				t.Errorf("applyPatch() changed the stored book to %+v", book)
+			}
+		})
+	}
+}
+
+// newPatchTestApp func for creating an app serving PatchBook from an in-memory
+// database, with an access token of the owner of the created books.
+func newPatchTestApp(t *testing.T) (*fiber.App, *database.Queries, string, uuid.UUID) {
+	t.Helper()
+
+	viper.Set("DB_DRIVER", "memory")
+	viper.Set("MW_JWT_CONTEXTKEY", "jwt")
+	db, err := database.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := auth.New(auth.Config{Algorithm: "HS256", Secret: "secret", AccessTTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uuid.New()
+	tokens, err := authenticator.Issue(owner, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	app.Patch("/books/:id", middleware.Protected(authenticator), NewHandler(db, nil, nil).PatchBook)
+
+	return app, db, tokens.AccessToken, owner
+}
+
+func TestPatchBook(t *testing.T) {
+	app, db, token, owner := newPatchTestApp(t)
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		status      books.Status
+		patch       string
+		wantStatus  int
+		want        func(b *books.Book) bool
+	}{
+		{
+			name:        "merge title",
+			contentType: MIMEMergePatch,
+			patch:       `{"title":"New title"}`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.Title == "New title" && b.Author == "Author" && b.Version == 2
+			},
+		},
+		{
+			name:        "merge nested attributes",
+			contentType: MIMEMergePatch + "; charset=utf-8",
+			patch:       `{"book_attrs":{"rating":9}}`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Rating == 9 && b.BookAttrs.Description == "Description"
+			},
+		},
+		{
+			name:        "merge removes attribute",
+			contentType: MIMEMergePatch,
+			patch:       `{"book_attrs":{"description":null}}`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Description == "" && b.BookAttrs.Rating == 5
+			},
+		},
+		{
+			name:        "merge allowed status change",
+			contentType: MIMEMergePatch,
+			patch:       `{"book_status":"active"}`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.BookStatus == books.StatusActive && b.StatusChangedAt != nil
+			},
+		},
+		{
+			name:        "merge denied status change",
+			contentType: MIMEMergePatch,
+			patch:       `{"book_status":"archived"}`,
+			wantStatus:  fiber.StatusConflict,
+		},
+		{
+			name:        "merge keeps owner and ID",
+			contentType: MIMEMergePatch,
+			patch:       `{"id":"` + uuid.NewString() + `","user_id":"` + uuid.NewString() + `","author":"Other"}`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.UserID == owner && b.Author == "Other"
+			},
+		},
+		{
+			name:        "merge invalid field",
+			contentType: MIMEMergePatch,
+			patch:       `{"title":""}`,
+			wantStatus:  fiber.StatusBadRequest,
+		},
+		{
+			name:        "merge invalid JSON",
+			contentType: MIMEMergePatch,
+			patch:       `{"title":`,
+			wantStatus:  fiber.StatusBadRequest,
+		},
+		{
+			name:        "merge invalid status",
+			contentType: MIMEMergePatch,
+			patch:       `{"book_status":"published"}`,
+			wantStatus:  fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:        "JSON patch replace",
+			contentType: MIMEJSONPatch,
+			patch:       `[{"op":"replace","path":"/book_attrs/rating","value":1},{"op":"replace","path":"/title","value":"Patched"}]`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.BookAttrs.Rating == 1 && b.Title == "Patched"
+			},
+		},
+		{
+			name:        "JSON patch status transition",
+			contentType: MIMEJSONPatch,
+			status:      books.StatusActive,
+			patch:       `[{"op":"replace","path":"/book_status","value":"archived"}]`,
+			wantStatus:  fiber.StatusOK,
+			want: func(b *books.Book) bool {
+				return b.BookStatus == books.StatusArchived
+			},
+		},
+		{
+			name:        "JSON patch failed test",
+			contentType: MIMEJSONPatch,
+			patch:       `[{"op":"test","path":"/title","value":"Other"},{"op":"replace","path":"/title","value":"Patched"}]`,
+			wantStatus:  fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:        "JSON patch missing path",
+			contentType: MIMEJSONPatch,
+			patch:       `[{"op":"remove","path":"/subtitle"}]`,
+			wantStatus:  fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:        "JSON patch not a list",
+			contentType: MIMEJSONPatch,
+			patch:       `{"op":"replace","path":"/title","value":"Patched"}`,
+			wantStatus:  fiber.StatusBadRequest,
+		},
+		{
+			name:        "current version",
+			contentType: MIMEMergePatch,
+			ifMatch:     `"1"`,
+			patch:       `{"title":"New title"}`,
+			wantStatus:  fiber.StatusOK,
+		},
+		{
+			name:        "stale version",
+			contentType: MIMEMergePatch,
+			ifMatch:     `"2"`,
+			patch:       `{"title":"New title"}`,
+			wantStatus:  fiber.StatusPreconditionFailed,
+		},
+		{
+			name:        "unsupported media type",
+			contentType: fiber.MIMEApplicationJSON,
+			patch:       `{"title":"New title"}`,
+			wantStatus:  fiber.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a book of the owner for each case.
+			now := time.Now()
+			book := &books.Book{
+				ID:              uuid.New(),
+				CreatedAt:       now,
+				UpdatedAt:       now,
+				Version:         1,
+				UserID:          owner,
+				Title:           "Title",
+				Author:          "Author",
+				BookStatus:      tt.status,
+				StatusChangedAt: &now,
+				BookAttrs:       books.BookAttrs{Description: "Description", Rating: 5},
+			}
+			if err := db.CreateBook(book, owner); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(fiber.MethodPatch, "/books/"+book.ID.String(), strings.NewReader(tt.patch))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("PATCH status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			stored, err := db.GetBook(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				// Rejected patches do not change the book.
+				if stored.Version != 1 {
+					t.Errorf("rejected PATCH changed the book to version %d", stored.Version)
+				}
+				return
+			}
+			if etag := resp.Header.Get(fiber.HeaderETag); etag != `"2"` {
+				t.Errorf("ETag = %s, want %q", etag, `"2"`)
+			}
+			if tt.want != nil && !tt.want(&stored) {
+				t.Errorf("patched book = %+v", stored)
+			}
+		})
+	}
+}
+
+func TestPatchBookNotFound(t *testing.T) {
+	app, _, token, _ := newPatchTestApp(t)
+
+	req := httptest.NewRequest(fiber.MethodPatch, "/books/"+uuid.NewString(), strings.NewReader(`{"title":"New title"}`))
+	req.Header.Set(fiber.HeaderContentType, MIMEMergePatch)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("PATCH status = %d, want %d", resp.StatusCode, fiber.StatusNotFound)
+	}
+}
@@ -0,0 +1,254 @@
+package books
+
+import (
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/problem"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"strconv"
+	"time"
+)
+
+// GetBookRevisions method gets a page of revisions of book by given ID.
+// @Description Get revisions of book by given ID, the latest first. Books in the trash have revisions too.
+// @Summary get book revisions
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of revisions to skip"
+// @Success 200 {array} books.Revision
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/revisions [get]
+// @Router /v2/books/{id}/revisions [get]
+func (h *Handler) GetBookRevisions(c *fiber.Ctx) error {
+	// Catch book ID from URL and check access.
+	book, err := h.revisionedBook(c)
+	if err != nil {
+		return err
+	}
+
+	// Read pagination params.
+	limit, errLimit := strconv.Atoi(c.Query("limit", viper.GetString("API_PAGINATION_DEFAULT_LIMIT")))
+	offset, errOffset := strconv.Atoi(c.Query("offset", "0"))
+	if errLimit != nil || errOffset != nil ||
+		limit < 1 || limit > viper.GetInt("API_PAGINATION_MAX_LIMIT") || offset < 0 {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, "invalid limit or offset")
+	}
+
+	// Get a page of revisions.
+	revisions, total, err := h.db.GetBookRevisions(book.ID, limit, offset)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":     false,
+		"msg":       nil,
+		"count":     len(revisions),
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+		"revisions": h.presentRevisions(revisions),
+	})
+}
+
+// GetBookRevisionsDiff method gets the fields changed between two revisions of book by given ID.
+// @Description Get the field-level diff between two revisions of book by given ID.
+// @Summary diff book revisions
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param from query integer true "Revision to compare from"
+// @Param to query integer false "Revision to compare to, the latest by default"
+// @Success 200 {array} books.Change
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/revisions/diff [get]
+// @Router /v2/books/{id}/revisions/diff [get]
+func (h *Handler) GetBookRevisionsDiff(c *fiber.Ctx) error {
+	// Catch book ID from URL and check access.
+	book, err := h.revisionedBook(c)
+	if err != nil {
+		return err
+	}
+
+	// Read compared revisions.
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := queryInt(c, "to", book.Version)
+	if errFrom != nil || errTo != nil {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, "from and to must be revision numbers")
+	}
+
+	// Get both revisions.
+	fromRevision, err := h.db.GetBookRevision(book.ID, from)
+	if err != nil {
+		return revisionError(err, from)
+	}
+	toRevision, err := h.db.GetBookRevision(book.ID, to)
+	if err != nil {
+		return revisionError(err, to)
+	}
+
+	// Compare revisions.
+	changes, err := books.DiffRevisions(&fromRevision, &toRevision)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"msg":     nil,
+		"from":    from,
+		"to":      to,
+		"changes": h.presentChanges(changes),
+	})
+}
+
+// RestoreBookRevision method for rolls book by given ID back to a revision.
+// @Description Roll book back to the content of a revision. The rollback is recorded as a new revision.
+// @Summary restore book revision
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param rev path integer true "Revision"
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 200 {object} books.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/revisions/{rev}/restore [post]
+// @Router /v2/books/{id}/revisions/{rev}/restore [post]
+func (h *Handler) RestoreBookRevision(c *fiber.Ctx) error {
+	// Catch book ID and revision from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+	rev, err := strconv.Atoi(c.Params("rev"))
+	if err != nil {
+		return problem.New(fiber.StatusBadRequest, "revision must be an integer")
+	}
+
+	// Checking, if book with given ID is exists.
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkIfMatch(c, &foundedBook)
+	if err != nil {
+		// Return status 412 or 428 and precondition error.
+		return err
+	}
+
+	// Get the revision to roll back to.
+	revision, err := h.db.GetBookRevision(foundedBook.ID, rev)
+	if err != nil {
+		return revisionError(err, rev)
+	}
+
+	// Set content of the revision, keeping the owner of the book:
+	book := foundedBook
+	book.Title = revision.Book.Title
+	book.Author = revision.Book.Author
+	book.BookStatus = revision.Book.BookStatus
+	book.BookAttrs = revision.Book.BookAttrs
+	book.UpdatedAt = time.Now()
+	book.Version = version
+
+	// Keep the current cover, replaced covers of revisions are deleted.
+	if _, ok := coverUpload(&book); ok {
+		book.BookAttrs.Picture = foundedBook.BookAttrs.Picture
+	}
+
+	// Checking, if the book may change back to the status of the revision.
+	if err := changeStatus(&book, &foundedBook); err != nil {
+		return err
+	}
+
+	// Update book by given ID.
+	if err := h.db.UpdateBook(foundedBook.ID, &book, principal.UserID); err != nil {
+		// Return status 412, if book was changed meanwhile.
+		if errors.Is(err, books.ErrVersionConflict) {
+			return problem.New(fiber.StatusPreconditionFailed, err.Error())
+		}
+
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Delete the cover, if the picture of the revision replaced it.
+	DeleteReplacedCover(c.UserContext(), h.blobs, &foundedBook, &book)
+
+	// Return status 200 OK with the new version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(&book))
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"book":  h.presentBook(&book),
+	})
+}
+
+// revisionedBook method for getting the book, live or in the trash, whose
+// revisions are requested. It returns a problem, if the book can not be accessed.
+func (h *Handler) revisionedBook(c *fiber.Ctx) (books.Book, error) {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return books.Book{}, problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is exists.
+	book, err := h.db.GetBook(id)
+	if errors.Is(err, models.ErrNotFound) {
+		book, err = h.db.GetDeletedBook(id)
+	}
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return book, problem.New(fiber.StatusNotFound, "book with this ID not found")
+		}
+		return book, err
+	}
+
+	// Checking, if caller may see the history of the book.
+	if principal, _ := middleware.Principal(c); !policy.CanModifyBook(principal, &book) {
+		return book, problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may see its revisions")
+	}
+
+	return book, nil
+}
+
+// revisionError func for creating the problem of a failed revision lookup.
+func revisionError(err error, rev int) error {
+	if errors.Is(err, models.ErrNotFound) {
+		return problem.New(fiber.StatusNotFound, fmt.Sprintf("revision %d of the book not found", rev))
+	}
+	return err
+}
@@ -1,11 +1,85 @@
 package books
 
-import "github.com/gofiber/fiber/v2"
-
-func Routes(route fiber.Router) {
-	route.Get("/books", GetBooks)
-	route.Get("/books/:id", GetBook)
-	route.Put("/books/:id", UpdateBook)
-	route.Post("/books", NewBook)
-	route.Delete("/books/:id", DeleteBook)
+import (
+	"fiber-api-example/app/config"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/spf13/viper"
+)
+
+// Routes func for registering the book routes of API v1,
+// books are updated and deleted by the ID given in the body.
+// Responses keep the v1 shape of books with numeric statuses.
+func Routes(route fiber.Router, h *Handler, a *auth.Authenticator) {
+	v1 := *h
+	v1.v1 = true
+	h = &v1
+
+	idempotent := idempotency(h)
+	routes(route, h, a, idempotent)
+	route.Put("/books/:id", middleware.Protected(a), idempotent, h.UpdateBook)
+	route.Delete("/books/:id", middleware.Protected(a), idempotent, h.DeleteBook)
+}
+
+// RoutesV2 func for registering the book routes of API v2,
+// books are updated and deleted by the ID given in the URL.
+func RoutesV2(route fiber.Router, h *Handler, a *auth.Authenticator) {
+	idempotent := idempotency(h)
+	routes(route, h, a, idempotent)
+	route.Put("/books/:id", middleware.Protected(a), idempotent, h.UpdateBookV2)
+	route.Delete("/books/:id", middleware.Protected(a), idempotent, h.DeleteBookV2)
+}
+
+// routes func for registering the book routes shared by all API versions.
+// Writes are idempotent, if an Idempotency-Key is given.
+func routes(route fiber.Router, h *Handler, a *auth.Authenticator, idempotent fiber.Handler) {
+	// Routes for the trash, registered before /books/:id to take precedence:
+	route.Get("/books/trash", middleware.Protected(a), h.GetDeletedBooks)
+	route.Post("/books/trash/:id/restore", middleware.Protected(a), idempotent, h.RestoreBook)
+	route.Delete("/books/trash/:id", middleware.Protected(a), idempotent, h.PurgeBook)
+
+	// Routes for streams of book changes, registered before /books/:id to take precedence:
+	route.Get("/books/stream", middleware.Authenticated(a), h.StreamBooks)
+	route.Get("/books/stream/ws", middleware.Authenticated(a), h.UpgradeBookStream, websocket.New(h.StreamBooksWebSocket))
+
+	route.Get("/books", h.GetBooks)
+	route.Get("/books/:id", h.GetBook)
+	route.Get("/books/:id/cover", h.GetBookCover)
+	route.Get("/books/:id/files", middleware.Authenticated(a), h.GetBookFiles)
+	route.Get("/books/:id/files/:file", middleware.Authenticated(a), h.DownloadBookFile)
+	route.Get("/users/:id/books", h.GetUserBooks)
+
+	// Routes for authenticated users:
+	route.Patch("/books/:id", middleware.Protected(a), idempotent, h.PatchBook)
+	route.Post("/books", middleware.Protected(a), idempotent, h.NewBook)
+	upload(route, fiber.MethodPut, "/books/:id/cover", "COVER_MAX_SIZE", middleware.Protected(a), idempotent, h.UploadBookCover)
+	upload(route, fiber.MethodPost, "/books/:id/files", "FILE_MAX_SIZE", middleware.Protected(a), idempotent, h.UploadBookFile)
+	route.Delete("/books/:id/files/:file", middleware.Protected(a), idempotent, h.DeleteBookFile)
+	route.Get("/books/:id/revisions", middleware.Protected(a), h.GetBookRevisions)
+	route.Get("/books/:id/revisions/diff", middleware.Protected(a), h.GetBookRevisionsDiff)
+	route.Post("/books/:id/revisions/:rev/restore", middleware.Protected(a), idempotent, h.RestoreBookRevision)
+	for _, transition := range books.Transitions {
+		route.Post("/books/:id/"+transition.Name, middleware.Protected(a), idempotent, h.TransitionBook(transition))
+	}
+}
+
+// idempotency func for creating the middleware storing responses of book writes.
+func idempotency(h *Handler) fiber.Handler {
+	return middleware.Idempotency(&middleware.IdempotencyConfig{
+		Store: h.db,
+		TTL:   viper.GetDuration("IDEMPOTENCY_TTL"),
+	})
+}
+
+// upload func for registering an upload route, its body is streamed up to
+// the max size of the given key, e.g. FILE_MAX_SIZE, instead of being read
+// up to FIBER_BODYLIMIT. The limit is checked after authentication.
+func upload(route fiber.Router, method, path, key string, authenticated fiber.Handler, handlers ...fiber.Handler) {
+	middleware.StreamBody(method, path)
+
+	limit := middleware.StreamedBodyLimit(config.UploadBodyLimit(key))
+	route.Add(method, path, append([]fiber.Handler{authenticated, limit}, handlers...)...)
 }
@@ -0,0 +1,131 @@
+package books
+
+import (
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// TransitionBook method for creating a handler changing the status of book
+// by given ID with the given transition, e.g. publish or archive.
+// @Description Change the status of a book: publish (draft or archived to active), unpublish (active to draft), archive (active to archived) or withdraw (any to withdrawn).
+// @Summary change book status
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param transition path string true "Transition" Enums(publish, unpublish, archive, withdraw)
+// @Param If-Match header string false "Entity tag of the book version"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 200 {object} books.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/{id}/{transition} [post]
+// @Router /v2/books/{id}/{transition} [post]
+func (h *Handler) TransitionBook(transition books.Transition) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Catch book ID from URL.
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+		}
+
+		// Checking, if book with given ID is exists.
+		foundedBook, err := h.db.GetBook(id)
+		if err != nil {
+			// Return status 404, if not found, or the storage error.
+			if errors.Is(err, models.ErrNotFound) {
+				return problem.New(fiber.StatusNotFound, "book with this ID not found")
+			}
+			return err
+		}
+
+		// Checking, if caller may change the book.
+		principal, _ := middleware.Principal(c)
+		if !policy.CanModifyBook(principal, &foundedBook) {
+			// Return status 403 and forbidden error.
+			return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+		}
+
+		// Checking, if book was not changed since the client read it.
+		version, err := checkIfMatch(c, &foundedBook)
+		if err != nil {
+			// Return status 412 or 428 and precondition error.
+			return err
+		}
+
+		// Change status of the book.
+		book := foundedBook
+		book.UpdatedAt = time.Now()
+		book.Version = version
+		if err := transition.Apply(&book, book.UpdatedAt); err != nil {
+			// Return status 409, if the book is not in a status the transition starts from.
+			return problem.New(fiber.StatusConflict, err.Error())
+		}
+
+		// Record status change of book by given ID.
+		if err := h.db.TransitionBook(foundedBook.ID, &book, transition.Name, principal.UserID); err != nil {
+			// Return status 412, if book was changed meanwhile.
+			if errors.Is(err, books.ErrVersionConflict) {
+				return problem.New(fiber.StatusPreconditionFailed, err.Error())
+			}
+
+			// Return error, the error handler maps it to its status.
+			return err
+		}
+
+		// Return status 200 OK with the new version as entity tag.
+		c.Set(fiber.HeaderETag, bookETag(&book))
+		return c.JSON(fiber.Map{
+			"error": false,
+			"msg":   nil,
+			"book":  h.presentBook(&book),
+		})
+	}
+}
+
+// statusUnset is the status of a book read from a full update before
+// parsing the request body, so a missing status can be told from draft.
+const statusUnset books.Status = -1
+
+// isInitialStatus func for checking, if new books may be created with the status.
+func isInitialStatus(status books.Status) bool {
+	for _, initial := range books.InitialStatuses {
+		if status == initial {
+			return true
+		}
+	}
+	return false
+}
+
+// initialStatusNames func for listing the statuses new books may be created with,
+// e.g. "draft or active".
+func initialStatusNames() string {
+	names := make([]string, len(books.InitialStatuses))
+	for i, status := range books.InitialStatuses {
+		names[i] = status.String()
+	}
+	return strings.Join(names, " or ")
+}
+
+// changeStatus func for changing the status of a stored book to the one
+// requested by a full or partial update, at the time of the update.
+// It returns a 409 problem, if no transition leads to the requested status.
+func changeStatus(book, foundedBook *books.Book) error {
+	requested := book.BookStatus
+	book.BookStatus = foundedBook.BookStatus
+	book.StatusChangedAt = foundedBook.StatusChangedAt
+
+	if err := book.ChangeStatus(requested, book.UpdatedAt); err != nil {
+		// Return status 409 and transition error.
+		return problem.New(fiber.StatusConflict, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package books
+
+import (
+	"bufio"
+	"encoding/json"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/problem"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/spf13/viper"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderLastEventID is sent by reconnecting Server-Sent Events clients.
+const HeaderLastEventID = "Last-Event-ID"
+
+// streamFilterKey is the local of the filter of WebSocket streams.
+const streamFilterKey = "books.streamFilter"
+
+// streamWriteTimeout is the time to wait for a WebSocket client to receive a message.
+const streamWriteTimeout = 10 * time.Second
+
+// StreamBooks method streams changes of books as Server-Sent Events.
+// @Description Stream changes of books as Server-Sent Events. Changes of drafts and withdrawn books are only sent to who may change them, like their actors. The event name is the event type, e.g. book.created, the data is the event with the changed book. Reconnecting clients resume after the Last-Event-ID header or last_event_id param. A reset event tells, that changes were missed and the books must be reloaded. Clients falling too far behind are disconnected and may resume.
+// @Summary stream changes of books
+// @Tags Books
+// @Produce text/event-stream
+// @Param author query string false "Author"
+// @Param book_status query string false "Book status" Enums(draft, active, archived, withdrawn)
+// @Param last_event_id query string false "ID of the last received event"
+// @Success 200 {string} string "event stream"
+// @Router /v1/books/stream [get]
+// @Router /v2/books/stream [get]
+func (h *Handler) StreamBooks(c *fiber.Ctx) error {
+	// Read filter params.
+	filter, err := parseStreamFilter(c.Query("author"), c.Query("book_status"))
+	if err != nil {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, err.Error())
+	}
+	filter.principal, _ = middleware.Principal(c)
+
+	// Subscribe to changes after the last received one.
+	sub, err := h.stream.Subscribe(c.Get(HeaderLastEventID, c.Query("last_event_id")))
+	if err != nil {
+		// Return status 503, the server is shutting down.
+		return problem.New(fiber.StatusServiceUnavailable, "server is shutting down, reconnect later")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+
+	// Stream events, until the client disconnects or the subscription ends.
+	heartbeat := viper.GetDuration("STREAM_HEARTBEAT")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.stream.Unsubscribe(sub)
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		if sub.Reset {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, m := range sub.Replay {
+			if m, ok := filter.apply(m); ok {
+				writeEvent(w, m)
+			}
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		for {
+			select {
+			case m, ok := <-sub.Messages():
+				if !ok {
+					if sub.Overflowed() {
+						fmt.Fprint(w, ": slow consumer, reconnect with Last-Event-ID\n\n")
+						_ = w.Flush()
+					}
+					return
+				}
+				m, ok = filter.apply(m)
+				if !ok {
+					continue
+				}
+				writeEvent(w, m)
+			case <-ticker.C:
+				// Keep connection alive and detect disconnected clients.
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// UpgradeBookStream method checks the WebSocket upgrade and filter
+// of StreamBooksWebSocket.
+func (h *Handler) UpgradeBookStream(c *fiber.Ctx) error {
+	// Checking, if the client requests a WebSocket.
+	if !websocket.IsWebSocketUpgrade(c) {
+		// Return status 426 and error message.
+		return problem.New(fiber.StatusUpgradeRequired, "request must upgrade to a WebSocket")
+	}
+
+	// Read filter params.
+	filter, err := parseStreamFilter(c.Query("author"), c.Query("book_status"))
+	if err != nil {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, err.Error())
+	}
+	filter.principal, _ = middleware.Principal(c)
+	c.Locals(streamFilterKey, filter)
+
+	return c.Next()
+}
+
+// StreamBooksWebSocket method streams changes of books over a WebSocket.
+// @Description Stream changes of books over a WebSocket. Changes of drafts and withdrawn books are only sent to who may change them, like their actors. Every text message is a JSON object with the event ID, the event type and the event with the changed book as data. Reconnecting clients resume after the last_event_id param. A reset event tells, that changes were missed and the books must be reloaded. Clients falling too far behind are closed with status 1013 and may resume.
+// @Summary stream changes of books over a WebSocket
+// @Tags Books
+// @Param author query string false "Author"
+// @Param book_status query string false "Book status" Enums(draft, active, archived, withdrawn)
+// @Param last_event_id query string false "ID of the last received event"
+// @Success 101 {string} string "switching protocols"
+// @Router /v1/books/stream/ws [get]
+// @Router /v2/books/stream/ws [get]
+func (h *Handler) StreamBooksWebSocket(conn *websocket.Conn) {
+	filter, _ := conn.Locals(streamFilterKey).(streamFilter)
+
+	// Subscribe to changes after the last received one.
+	sub, err := h.stream.Subscribe(conn.Query("last_event_id"))
+	if err != nil {
+		closeStream(conn, websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	defer h.stream.Unsubscribe(sub)
+
+	// Read messages to handle control frames and detect closed connections.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(viper.GetDuration("STREAM_HEARTBEAT"))
+	defer ticker.Stop()
+
+	if sub.Reset {
+		if sendMessage(conn, streamMessage{Event: "reset"}) != nil {
+			return
+		}
+	}
+	for _, m := range sub.Replay {
+		if m, ok := filter.apply(m); ok && sendEvent(conn, m) != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case m, ok := <-sub.Messages():
+			if !ok {
+				if sub.Overflowed() {
+					closeStream(conn, websocket.CloseTryAgainLater, "slow consumer, reconnect with last_event_id")
+				} else {
+					closeStream(conn, websocket.CloseGoingAway, "server is shutting down")
+				}
+				return
+			}
+			if m, ok := filter.apply(m); ok && sendEvent(conn, m) != nil {
+				return
+			}
+		case <-ticker.C:
+			// Keep connection alive and detect disconnected clients.
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// streamFilter struct to describe the changes a stream subscribes to.
+// Purged books have no state, their events only match empty filters.
+type streamFilter struct {
+	author    string
+	status    *books.Status
+	principal auth.Principal
+}
+
+// parseStreamFilter func for reading the author and status filters.
+func parseStreamFilter(author, status string) (streamFilter, error) {
+	filter := streamFilter{author: author}
+	if status != "" {
+		s, err := books.ParseStatus(status)
+		if err != nil {
+			return filter, err
+		}
+		filter.status = &s
+	}
+	return filter, nil
+}
+
+// apply checks, if the message is a change of a book matching the filter,
+// which the subscriber may watch. The actor of the change is only sent to
+// subscribers, who may change the book.
+func (f streamFilter) apply(m outbox.Message) (outbox.Message, bool) {
+	if m.AggregateType != books.AggregateType {
+		return m, false
+	}
+
+	e := books.Event{}
+	if err := json.Unmarshal(m.Payload, &e); err != nil {
+		return m, false
+	}
+	switch {
+	case !policy.CanWatchBook(f.principal, &e.Book):
+		return m, false
+	case e.Type == books.EventPurged && (f.author != "" || f.status != nil):
+		return m, false
+	case f.author != "" && !strings.EqualFold(e.Book.Author, f.author):
+		return m, false
+	case f.status != nil && e.Book.BookStatus != *f.status:
+		return m, false
+	}
+
+	// Hide the actor from subscribers, who may not change the book.
+	if e.ActorID != nil && !policy.CanModifyBook(f.principal, &e.Book) {
+		e.ActorID = nil
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return m, false
+		}
+		m.Payload = payload
+	}
+	return m, true
+}
+
+// streamMessage struct to describe a message of WebSocket streams.
+type streamMessage struct {
+	ID    string          `json:"id,omitempty"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// writeEvent writes the message as a Server-Sent Event.
+func writeEvent(w *bufio.Writer, m outbox.Message) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.ID, m.EventType, m.Payload)
+}
+
+// sendEvent sends the message to a WebSocket.
+func sendEvent(conn *websocket.Conn, m outbox.Message) error {
+	return sendMessage(conn, streamMessage{
+		ID:    strconv.FormatInt(m.ID, 10),
+		Event: m.EventType,
+		Data:  m.Payload,
+	})
+}
+
+// sendMessage sends a JSON message to a WebSocket.
+func sendMessage(conn *websocket.Conn, msg streamMessage) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return conn.WriteJSON(msg)
+}
+
+// closeStream closes a WebSocket with the given status.
+func closeStream(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(streamWriteTimeout))
+}
@@ -0,0 +1,66 @@
+package books
+
+import (
+	"encoding/json"
+	"testing"
+
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/utils/auth"
+	"github.com/google/uuid"
+)
+
+func TestStreamFilterApply(t *testing.T) {
+	owner := uuid.New()
+	message := func(eventType string, status books.Status) outbox.Message {
+		e := books.Event{
+			ID:      uuid.New(),
+			Type:    eventType,
+			ActorID: &owner,
+			Book:    books.Book{ID: uuid.New(), UserID: owner, Author: "Author", BookStatus: status},
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return outbox.Message{AggregateType: books.AggregateType, EventType: eventType, Payload: payload}
+	}
+	draft := books.StatusDraft
+
+	tests := []struct {
+		name      string
+		filter    streamFilter
+		message   outbox.Message
+		want      bool
+		wantActor bool
+	}{
+		{name: "public book anonymous", message: message(books.EventUpdated, books.StatusActive), want: true},
+		{name: "draft anonymous", message: message(books.EventCreated, books.StatusDraft), want: false},
+		{name: "withdrawn other user", filter: streamFilter{principal: auth.Principal{UserID: uuid.New(), Role: "user"}}, message: message(books.EventUpdated, books.StatusWithdrawn), want: false},
+		{name: "draft owner", filter: streamFilter{principal: auth.Principal{UserID: owner, Role: "user"}}, message: message(books.EventCreated, books.StatusDraft), want: true, wantActor: true},
+		{name: "draft admin", filter: streamFilter{principal: auth.Principal{UserID: uuid.New(), Role: "admin"}}, message: message(books.EventCreated, books.StatusDraft), want: true, wantActor: true},
+		{name: "other author", filter: streamFilter{author: "Other"}, message: message(books.EventUpdated, books.StatusActive), want: false},
+		{name: "other status", filter: streamFilter{status: &draft}, message: message(books.EventUpdated, books.StatusActive), want: false},
+		{name: "purged anonymous", message: message(books.EventPurged, books.StatusDraft), want: false},
+		{name: "other aggregate", message: outbox.Message{AggregateType: "user", Payload: []byte(`{}`)}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := tt.filter.apply(tt.message)
+			if ok != tt.want {
+				t.Fatalf("apply() = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			e := books.Event{}
+			if err := json.Unmarshal(m.Payload, &e); err != nil {
+				t.Fatal(err)
+			}
+			if (e.ActorID != nil) != tt.wantActor {
+				t.Errorf("apply() actor = %v, want actor %v", e.ActorID, tt.wantActor)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package books
+
+import (
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetDeletedBooks method gets a page of books in the trash.
+// @Description Get a page of deleted books. Admins see the whole trash, other users only their own books.
+// @Summary get deleted books
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of books to skip"
+// @Param user_id query string false "Owner ID"
+// @Param sort query string false "Sort fields, e.g. -created_at,title"
+// @Param cursor query string false "Keyset pagination cursor, empty for the first page"
+// @Success 200 {array} books.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/trash [get]
+// @Router /v2/books/trash [get]
+func (h *Handler) GetDeletedBooks(c *fiber.Ctx) error {
+	// Read pagination, filter and sort params.
+	params, err := parseListParams(c)
+	if err != nil {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, err.Error())
+	}
+	params.Filter.Deleted = true
+
+	// Restrict listing to own books, if caller may not see the whole trash.
+	if principal, _ := middleware.Principal(c); !policy.CanListAllDeletedBooks(principal) {
+		params.Filter.UserID = &principal.UserID
+	}
+
+	return h.listBooks(c, params)
+}
+
+// RestoreBook method for restores book by given ID from the trash.
+// @Description Restore deleted book by given ID.
+// @Summary restore deleted book by given ID
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 200 {object} books.Book
+// @Security ApiKeyAuth
+// @Router /v1/books/trash/{id}/restore [post]
+// @Router /v2/books/trash/{id}/restore [post]
+func (h *Handler) RestoreBook(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is in the trash.
+	foundedBook, err := h.db.GetDeletedBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found in the trash")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Restore book by given ID.
+	if err := h.db.RestoreBook(foundedBook.ID, principal.UserID); err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Get restored book.
+	book, err := h.db.GetBook(foundedBook.ID)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK with the version as entity tag.
+	c.Set(fiber.HeaderETag, bookETag(&book))
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"book":  h.presentBook(&book),
+	})
+}
+
+// PurgeBook method for permanently deletes book by given ID from the trash.
+// @Description Permanently delete book by given ID from the trash.
+// @Summary purge deleted book by given ID
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID"
+// @Param Idempotency-Key header string false "Key to safely retry the request, its response is replayed"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/books/trash/{id} [delete]
+// @Router /v2/books/trash/{id} [delete]
+func (h *Handler) PurgeBook(c *fiber.Ctx) error {
+	// Catch book ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID")
+	}
+
+	// Checking, if book with given ID is in the trash.
+	foundedBook, err := h.db.GetDeletedBook(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "book with this ID not found in the trash")
+		}
+		return err
+	}
+
+	// Checking, if caller may change the book.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanModifyBook(principal, &foundedBook) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it")
+	}
+
+	// Purge book by given ID.
+	if err := h.db.PurgeBook(foundedBook.ID); err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Delete cover and files of the purged book, leftovers are only logged.
+	if err := storage.DeleteBookBlobs(c.UserContext(), h.blobs, foundedBook.ID); err != nil {
+		logger.Error("Can't delete blobs of purged book "+foundedBook.ID.String()+": ", err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,156 @@
+package books
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"fiber-api-example/app/models/books"
+	"github.com/google/uuid"
+)
+
+// bookV1 struct to describe a book in responses of API v1.
+// The shape is frozen: statuses are written as numbers and fields
+// added to books.Book later are only sent by newer versions.
+type bookV1 struct {
+	ID         uuid.UUID   `json:"id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	DeletedAt  *time.Time  `json:"deleted_at,omitempty"`
+	Version    int         `json:"version"`
+	UserID     uuid.UUID   `json:"user_id"`
+	Title      string      `json:"title"`
+	Author     string      `json:"author"`
+	BookStatus int         `json:"book_status"`
+	BookAttrs  bookAttrsV1 `json:"book_attrs"`
+}
+
+// bookAttrsV1 struct to describe book attributes in responses of API v1.
+type bookAttrsV1 struct {
+	Picture     string `json:"picture"`
+	Description string `json:"description"`
+	Rating      int    `json:"rating"`
+}
+
+// bookV1Fields are the JSON names of the fields of bookV1.
+var bookV1Fields = jsonFields(reflect.TypeOf(bookV1{}))
+
+// revisionV1 struct to describe a revision in responses of API v1.
+type revisionV1 struct {
+	BookID    uuid.UUID  `json:"book_id"`
+	Revision  int        `json:"revision"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    *uuid.UUID `json:"user_id"`
+	Action    string     `json:"action"`
+	Book      bookV1     `json:"book"`
+}
+
+// toBookV1 func for converting a book into its API v1 shape.
+func toBookV1(b *books.Book) bookV1 {
+	return bookV1{
+		ID:         b.ID,
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+		DeletedAt:  b.DeletedAt,
+		Version:    b.Version,
+		UserID:     b.UserID,
+		Title:      b.Title,
+		Author:     b.Author,
+		BookStatus: int(b.BookStatus),
+		BookAttrs: bookAttrsV1{
+			Picture:     b.BookAttrs.Picture,
+			Description: b.BookAttrs.Description,
+			Rating:      b.BookAttrs.Rating,
+		},
+	}
+}
+
+// presentBook method for getting a book in the shape of the API version
+// served by the handler.
+func (h *Handler) presentBook(b *books.Book) interface{} {
+	if !h.v1 {
+		return b
+	}
+	return toBookV1(b)
+}
+
+// presentBooks method for getting books in the shape of the API version
+// served by the handler.
+func (h *Handler) presentBooks(list []books.Book) interface{} {
+	if !h.v1 {
+		return list
+	}
+
+	v1 := make([]bookV1, 0, len(list))
+	for i := range list {
+		v1 = append(v1, toBookV1(&list[i]))
+	}
+	return v1
+}
+
+// presentRevisions method for getting revisions in the shape of the API
+// version served by the handler.
+func (h *Handler) presentRevisions(list []books.Revision) interface{} {
+	if !h.v1 {
+		return list
+	}
+
+	v1 := make([]revisionV1, 0, len(list))
+	for _, r := range list {
+		book := books.Book(r.Book)
+		v1 = append(v1, revisionV1{
+			BookID:    r.BookID,
+			Revision:  r.Revision,
+			CreatedAt: r.CreatedAt,
+			UserID:    r.UserID,
+			Action:    r.Action,
+			Book:      toBookV1(&book),
+		})
+	}
+	return v1
+}
+
+// presentChanges method for getting revision changes in the shape of the
+// API version served by the handler. On v1 changed statuses are numbers
+// and changes of fields missing in the v1 shape are left out.
+func (h *Handler) presentChanges(changes []books.Change) []books.Change {
+	if !h.v1 {
+		return changes
+	}
+
+	v1 := make([]books.Change, 0, len(changes))
+	for _, change := range changes {
+		if !bookV1Fields[strings.SplitN(change.Field, ".", 2)[0]] {
+			continue
+		}
+		if change.Field == "book_status" {
+			change.From = statusNumber(change.From)
+			change.To = statusNumber(change.To)
+		}
+		v1 = append(v1, change)
+	}
+	return v1
+}
+
+// statusNumber func for converting a status name of a diff into its number.
+func statusNumber(value interface{}) interface{} {
+	name, ok := value.(string)
+	if !ok {
+		return value
+	}
+	status, err := books.ParseStatus(name)
+	if err != nil {
+		return value
+	}
+	return int(status)
+}
+
+// jsonFields func for getting the JSON names of the fields of a struct type.
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		fields[name] = true
+	}
+	return fields
+}
@@ -0,0 +1,109 @@
+package books
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// update rewrites the golden files by `go test ./app/api/books -update`.
+// They describe the frozen API v1, so they are not updated for new fields.
+var update = flag.Bool("update", false, "update golden files")
+
+// TestV1Contract checks the responses of API v1 against golden files,
+// so changes of books.Book do not leak into the frozen v1 shape.
+func TestV1Contract(t *testing.T) {
+	viper.Set("DB_DRIVER", "memory")
+	viper.Set("MW_JWT_CONTEXTKEY", "jwt")
+	viper.Set("API_PAGINATION_DEFAULT_LIMIT", 10)
+	viper.Set("API_PAGINATION_MAX_LIMIT", 100)
+	db, err := database.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := auth.New(auth.Config{Algorithm: "HS256", Secret: "secret", AccessTTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Store a book with fixed values.
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	book := &books.Book{
+		ID:              uuid.MustParse("2a0d2c6e-4f3b-4e0a-9d8e-0c6b7a1f5e21"),
+		CreatedAt:       created,
+		UpdatedAt:       created,
+		Version:         1,
+		UserID:          uuid.MustParse("7c1f0b9a-3d2e-4b5c-8a6d-9e0f1a2b3c4d"),
+		Title:           "Title",
+		Author:          "Author",
+		BookStatus:      books.StatusActive,
+		StatusChangedAt: &created,
+		BookAttrs:       books.BookAttrs{Picture: "", Description: "Description", Rating: 5},
+	}
+	if err := db.CreateBook(book, book.UserID); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	Routes(app.Group("/api/v1"), NewHandler(db, nil, nil), authenticator)
+
+	tests := []struct {
+		name   string
+		path   string
+		golden string
+	}{
+		{name: "get book", path: "/api/v1/books/" + book.ID.String(), golden: "v1_get_book.json"},
+		{name: "list books", path: "/api/v1/books", golden: "v1_list_books.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Compare indented JSON for readable golden files and diffs.
+			var got bytes.Buffer
+			if err := json.Indent(&got, body, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			got.WriteByte('\n')
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("response differs from %s:\ngot:\n%s\nwant:\n%s", path, got.Bytes(), want)
+			}
+		})
+	}
+}
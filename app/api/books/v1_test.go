@@ -0,0 +1,29 @@
+package books
+
+import (
+	"reflect"
+	"testing"
+
+	"fiber-api-example/app/models/books"
+)
+
+func TestPresentChangesV1(t *testing.T) {
+	changes := []books.Change{
+		{Field: "book_status", From: "draft", To: "active"},
+		{Field: "status_changed_at", From: nil, To: "2022-01-01T00:00:00Z"},
+		{Field: "book_attrs.rating", From: 1.0, To: 2.0},
+	}
+
+	v2 := (&Handler{}).presentChanges(append([]books.Change{}, changes...))
+	if !reflect.DeepEqual(v2, changes) {
+		t.Errorf("presentChanges() on v2 = %v, want %v", v2, changes)
+	}
+
+	want := []books.Change{
+		{Field: "book_status", From: 0, To: 1},
+		{Field: "book_attrs.rating", From: 1.0, To: 2.0},
+	}
+	if got := (&Handler{v1: true}).presentChanges(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("presentChanges() on v1 = %v, want %v", got, want)
+	}
+}
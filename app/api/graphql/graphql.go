@@ -0,0 +1,178 @@
+package graphql
+
+import (
+	"context"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+// Handler struct for the GraphQL handlers with their dependencies.
+type Handler struct {
+	db     *database.Queries
+	blobs  storage.BlobStore
+	schema graphql.Schema
+	limits Limits
+}
+
+// NewHandler func for creating the GraphQL handlers using the given database
+// and the store of covers.
+func NewHandler(db *database.Queries, blobs storage.BlobStore) *Handler {
+	h := &Handler{db: db, blobs: blobs, limits: GetLimits()}
+	h.schema = h.newSchema()
+	return h
+}
+
+// Request struct to describe a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query method executes a GraphQL query or mutation.
+// Requests, which can not be parsed, are not valid or exceed the limits,
+// get status 400, errors of the execution are part of the result.
+func (h *Handler) Query(c *fiber.Ctx) error {
+	// Create new Request struct
+	req := &Request{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(req); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Parse the query.
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		// Return status 400 and syntax error.
+		return c.Status(fiber.StatusBadRequest).JSON(&graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+	}
+
+	// Validate the query against the schema.
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		// Return status 400 and validation errors.
+		return c.Status(fiber.StatusBadRequest).JSON(&graphql.Result{Errors: result.Errors})
+	}
+
+	// Checking, if the query is not too deep or complex.
+	if err := h.limits.Check(doc, req.OperationName, req.Variables); err != nil {
+		// Return status 400 and limit error.
+		return c.Status(fiber.StatusBadRequest).JSON(&graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+	}
+
+	// Execute the query with the caller, if authenticated.
+	principal, authenticated := middleware.Principal(c)
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withPrincipal(c.UserContext(), principal, authenticated),
+	})
+
+	// Return status 200 OK.
+	return c.JSON(result)
+}
+
+// Playground method serves an in-browser IDE for the GraphQL endpoint.
+func (h *Handler) Playground(c *fiber.Ctx) error {
+	c.Type("html")
+	return c.SendString(playground)
+}
+
+// playgroundEnabled func for checking, if the playground is served,
+// which is only the case outside of production.
+func playgroundEnabled() bool {
+	return !strings.EqualFold(viper.GetString("APP_ENV"), "production")
+}
+
+// principalKey is the context key of the authenticated caller.
+type principalKey struct{}
+
+// withPrincipal func for passing the caller to the resolvers.
+func withPrincipal(ctx context.Context, principal auth.Principal, authenticated bool) context.Context {
+	if !authenticated {
+		return ctx
+	}
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// principal func for getting the caller of a resolver. The second value is false,
+// if the request is not authenticated.
+func principal(ctx context.Context) (auth.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(auth.Principal)
+	return p, ok
+}
+
+// resolverError struct for passing a problem to the client as GraphQL error,
+// its status and invalid fields are the extensions.
+type resolverError struct {
+	problem *problem.Problem
+}
+
+// newResolverError func for converting any error of a resolver.
+// Internal errors are logged and their details hidden like by the error handler.
+func newResolverError(err error) error {
+	p := problem.From(err)
+	if p.Status >= fiber.StatusInternalServerError {
+		logger.Error("GraphQL resolver failed: ", err)
+	}
+	return &resolverError{problem: p}
+}
+
+// Error method to make resolverError implement the error interface.
+func (e *resolverError) Error() string {
+	if e.problem.Detail != "" {
+		return e.problem.Detail
+	}
+	return e.problem.Title
+}
+
+// Extensions method to make resolverError implement gqlerrors.ExtendedError.
+func (e *resolverError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"type":   e.problem.Type,
+		"status": e.problem.Status,
+	}
+	if len(e.problem.Errors) > 0 {
+		extensions["errors"] = e.problem.Errors
+	}
+	return extensions
+}
+
+// playground is the page of the in-browser IDE, loaded from a CDN.
+const playground = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>GraphQL Playground</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@2/graphiql.min.css">
+</head>
+<body style="margin: 0;">
+  <div id="graphiql" style="height: 100vh;"></div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@2/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: '/graphql' });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(
+      React.createElement(GraphiQL, { fetcher: fetcher, headerEditorEnabled: true })
+    );
+  </script>
+</body>
+</html>
+`
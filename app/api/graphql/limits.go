@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/spf13/viper"
+)
+
+// Limits struct to describe the limits of GraphQL queries, zero disables a limit.
+type Limits struct {
+	// MaxDepth is the maximal nesting of fields.
+	MaxDepth int
+
+	// MaxComplexity is the maximal number of fields to resolve. The fields selected
+	// of a page count once per item, up to the limit argument or the default page size.
+	MaxComplexity int
+
+	// DefaultLimit and MaxLimit are the default and maximal size of pages.
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// GetLimits func for reading the GraphQL limits.
+func GetLimits() Limits {
+	return Limits{
+		MaxDepth:      viper.GetInt("GRAPHQL_MAX_DEPTH"),
+		MaxComplexity: viper.GetInt("GRAPHQL_MAX_COMPLEXITY"),
+		DefaultLimit:  viper.GetInt("API_PAGINATION_DEFAULT_LIMIT"),
+		MaxLimit:      viper.GetInt("API_PAGINATION_MAX_LIMIT"),
+	}
+}
+
+// Check method for checking the depth and complexity of the operation to execute.
+// The document must be valid, so fragments are known and do not form cycles.
+func (l Limits) Check(doc *ast.Document, operationName string, variables map[string]interface{}) error {
+	m := &measure{
+		limits:    l,
+		variables: variables,
+		fragments: map[string]*ast.FragmentDefinition{},
+		measured:  map[string]size{},
+	}
+
+	// Find the operation and the fragments it may use.
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		case *ast.FragmentDefinition:
+			m.fragments[d.Name.Value] = d
+		}
+	}
+	if operation == nil {
+		// The executor reports the unknown operation.
+		return nil
+	}
+
+	s := m.selectionSet(operation.SelectionSet)
+	if l.MaxDepth > 0 && s.depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", s.depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && s.complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", s.complexity, l.MaxComplexity)
+	}
+
+	return nil
+}
+
+// size struct to describe the depth and complexity of a selection.
+type size struct {
+	depth      int
+	complexity int
+}
+
+// measure struct for measuring the selections of an operation.
+// Fragments are measured once, however often they are spread.
+type measure struct {
+	limits    Limits
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	measured  map[string]size
+}
+
+// selectionSet method measures the fields of a selection set.
+func (m *measure) selectionSet(set *ast.SelectionSet) size {
+	total := size{}
+	if set == nil {
+		return total
+	}
+
+	for _, selection := range set.Selections {
+		s := size{}
+		switch selection := selection.(type) {
+		case *ast.Field:
+			s = m.selectionSet(selection.SelectionSet)
+			s.depth++
+			s.complexity = 1 + s.complexity*m.items(selection)
+		case *ast.InlineFragment:
+			s = m.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			s = m.fragment(selection.Name.Value)
+		}
+
+		if s.depth > total.depth {
+			total.depth = s.depth
+		}
+		total.complexity += s.complexity
+	}
+
+	return total
+}
+
+// fragment method measures the fields of a named fragment.
+func (m *measure) fragment(name string) size {
+	if s, ok := m.measured[name]; ok {
+		return s
+	}
+
+	s := size{}
+	if f, ok := m.fragments[name]; ok {
+		s = m.selectionSet(f.SelectionSet)
+	}
+	m.measured[name] = s
+
+	return s
+}
+
+// items method returns the number of items selected by a field,
+// which is the page size for pages, up to the maximal page size.
+func (m *measure) items(field *ast.Field) int {
+	if !pagedFields[field.Name.Value] {
+		return 1
+	}
+
+	limit := m.limits.DefaultLimit
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				limit = n
+			}
+		case *ast.Variable:
+			// Variables of JSON requests are numbers.
+			if n, ok := m.variables[value.Name.Value].(float64); ok {
+				limit = int(n)
+			}
+		}
+	}
+
+	if limit < 1 {
+		return 1
+	}
+	if m.limits.MaxLimit > 0 && limit > m.limits.MaxLimit {
+		return m.limits.MaxLimit
+	}
+	return limit
+}
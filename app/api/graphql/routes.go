@@ -0,0 +1,17 @@
+package graphql
+
+import (
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Routes func for registering the GraphQL endpoint, mutations authenticate
+// the caller by the bearer token. The playground is only served outside of production.
+func Routes(route fiber.Router, h *Handler, a *auth.Authenticator) {
+	route.Post("/graphql", middleware.Authenticated(a), h.Query)
+
+	if playgroundEnabled() {
+		route.Get("/graphql/playground", h.Playground)
+	}
+}
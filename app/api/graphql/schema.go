@@ -0,0 +1,412 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	booksapi "fiber-api-example/app/api/books"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/spf13/viper"
+)
+
+// pagedFields are the fields returning pages, their items are counted
+// up to the limit argument by the complexity limit.
+var pagedFields = map[string]bool{"books": true}
+
+// bookStatusEnum is the GraphQL type of book statuses.
+var bookStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "BookStatus",
+	Description: "Lifecycle state of a book.",
+	Values: graphql.EnumValueConfigMap{
+		"DRAFT":     &graphql.EnumValueConfig{Value: books.StatusDraft},
+		"ACTIVE":    &graphql.EnumValueConfig{Value: books.StatusActive},
+		"ARCHIVED":  &graphql.EnumValueConfig{Value: books.StatusArchived},
+		"WITHDRAWN": &graphql.EnumValueConfig{Value: books.StatusWithdrawn},
+	},
+})
+
+// bookAttrsType is the GraphQL type of books.BookAttrs.
+var bookAttrsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BookAttrs",
+	Fields: graphql.Fields{
+		"picture":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"rating":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// bookType is the GraphQL type of books.Book, fields resolve to the
+// struct fields of the same name.
+var bookType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Book",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"updatedAt": &graphql.Field{
+			Type:        graphql.DateTime,
+			Description: "Time of the last change, null if the book was never changed.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if b := p.Source.(*books.Book); !b.UpdatedAt.IsZero() {
+					return b.UpdatedAt, nil
+				}
+				return nil, nil
+			},
+		},
+		"version":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"userId":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Description: "ID of the owner."},
+		"title":           &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"author":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"bookStatus":      &graphql.Field{Type: graphql.NewNonNull(bookStatusEnum)},
+		"statusChangedAt": &graphql.Field{Type: graphql.DateTime},
+		"bookAttrs":       &graphql.Field{Type: graphql.NewNonNull(bookAttrsType)},
+	},
+})
+
+// bookPageType is the GraphQL type of a page of books.
+var bookPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BookPage",
+	Fields: graphql.Fields{
+		"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of books matching the filter."},
+		"limit":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"offset": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"items":  &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType)))},
+	},
+})
+
+// bookFilterInput is the GraphQL type of books.BookFilter.
+var bookFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "BookFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"userId":      &graphql.InputObjectFieldConfig{Type: graphql.ID, Description: "Owner ID."},
+		"author":      &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Author, case-insensitive exact match."},
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Title substring."},
+		"bookStatus":  &graphql.InputObjectFieldConfig{Type: bookStatusEnum},
+		"ratingMin":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"ratingMax":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"createdFrom": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"createdTo":   &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"updatedFrom": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"updatedTo":   &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+// bookInput is the GraphQL type of the fields of created and updated books.
+// They are checked by the validation of the REST handlers, so they may be null.
+var bookInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "BookInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"author": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"bookStatus": &graphql.InputObjectFieldConfig{
+			Type:        bookStatusEnum,
+			Description: "New books are drafts, updated books keep their status, if not given.",
+		},
+		"bookAttrs": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
+			Name: "BookAttrsInput",
+			Fields: graphql.InputObjectConfigFieldMap{
+				"picture":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+				"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+				"rating":      &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			},
+		})},
+	},
+})
+
+// newSchema method for creating the schema resolving with the handler's database.
+func (h *Handler) newSchema() graphql.Schema {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"book": &graphql.Field{
+				Type:        bookType,
+				Description: "Get book by given ID.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: h.resolveBook,
+			},
+			"books": &graphql.Field{
+				Type:        graphql.NewNonNull(bookPageType),
+				Description: "Get a page of books with filters and sorting.",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: bookFilterInput},
+					"sort":   &graphql.ArgumentConfig{Type: graphql.String, Description: "Sort fields, e.g. -created_at,title"},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, Description: "Page size"},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Number of books to skip"},
+				},
+				Resolve: h.resolveBooks,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": &graphql.Field{
+				Type:        graphql.NewNonNull(bookType),
+				Description: "Create a new book owned by the caller.",
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)},
+				},
+				Resolve: h.createBook,
+			},
+			"updateBook": &graphql.Field{
+				Type:        graphql.NewNonNull(bookType),
+				Description: "Update book by given ID, status changes must follow the allowed transitions.",
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)},
+					"version": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Version of the book the update is based on"},
+				},
+				Resolve: h.updateBook,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		// The schema is static, so errors are programming errors.
+		panic(fmt.Sprintf("graphql: invalid schema: %v", err))
+	}
+	return schema
+}
+
+// resolveBook method gets book by given ID.
+func (h *Handler) resolveBook(p graphql.ResolveParams) (interface{}, error) {
+	id, err := uuid.Parse(p.Args["id"].(string))
+	if err != nil {
+		return nil, newResolverError(problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID"))
+	}
+
+	// Get book by ID.
+	book, err := h.db.GetBook(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, newResolverError(problem.New(fiber.StatusNotFound, "book with the given ID is not found"))
+		}
+		return nil, newResolverError(err)
+	}
+
+	return &book, nil
+}
+
+// resolveBooks method gets a page of books matching the given filter.
+func (h *Handler) resolveBooks(p graphql.ResolveParams) (interface{}, error) {
+	params, err := listParams(p.Args)
+	if err != nil {
+		return nil, newResolverError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	// Get a page of books.
+	list, total, err := h.db.GetBooks(params)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	page := make([]*books.Book, len(list))
+	for i := range list {
+		page[i] = &list[i]
+	}
+	return map[string]interface{}{
+		"total":  total,
+		"limit":  params.Limit,
+		"offset": params.Offset,
+		"items":  page,
+	}, nil
+}
+
+// createBook method creates a new book owned by the caller.
+func (h *Handler) createBook(p graphql.ResolveParams) (interface{}, error) {
+	caller, ok := principal(p.Context)
+	if !ok {
+		return nil, newResolverError(problem.New(fiber.StatusUnauthorized, "missing or malformed JWT"))
+	}
+
+	// Read fields of the book.
+	book := &books.Book{}
+	readBookInput(book, p.Args["input"].(map[string]interface{}))
+
+	// Set initialized default data for book:
+	book.ID = uuid.New()
+	book.CreatedAt = time.Now()
+	book.UpdatedAt = book.CreatedAt
+	book.Version = 1
+	book.UserID = caller.UserID
+	book.StatusChangedAt = &book.CreatedAt
+
+	// Validate book fields like the REST handler.
+	if err := booksapi.ValidateNewBook(book); err != nil {
+		return nil, newResolverError(err)
+	}
+
+	// Checking, if owner of the book is exists.
+	if _, err := h.db.GetUser(book.UserID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, newResolverError(problem.New(fiber.StatusUnauthorized, "user of the token is not found"))
+		}
+		return nil, newResolverError(err)
+	}
+
+	// Create book.
+	if err := h.db.CreateBook(book, caller.UserID); err != nil {
+		return nil, newResolverError(err)
+	}
+
+	return book, nil
+}
+
+// updateBook method replaces the fields of book by given ID.
+func (h *Handler) updateBook(p graphql.ResolveParams) (interface{}, error) {
+	caller, ok := principal(p.Context)
+	if !ok {
+		return nil, newResolverError(problem.New(fiber.StatusUnauthorized, "missing or malformed JWT"))
+	}
+
+	id, err := uuid.Parse(p.Args["id"].(string))
+	if err != nil {
+		return nil, newResolverError(problem.Wrap(fiber.StatusBadRequest, err, "book ID must be a UUID"))
+	}
+
+	// Checking, if book with given ID is exists.
+	foundedBook, err := h.db.GetBook(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, newResolverError(problem.New(fiber.StatusNotFound, "book with this ID not found"))
+		}
+		return nil, newResolverError(err)
+	}
+
+	// Checking, if caller may change the book.
+	if !policy.CanModifyBook(caller, &foundedBook) {
+		return nil, newResolverError(problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it"))
+	}
+
+	// Checking, if book was not changed since the client read it,
+	// the version replaces the If-Match header.
+	if version, ok := p.Args["version"].(int); ok {
+		if version != foundedBook.Version {
+			return nil, newResolverError(problem.New(fiber.StatusPreconditionFailed, books.ErrVersionConflict.Error()))
+		}
+	} else if viper.GetBool("API_REQUIRE_IF_MATCH") {
+		return nil, newResolverError(problem.New(fiber.StatusPreconditionRequired, "version is required"))
+	}
+
+	// Read fields of the book, keeping the status, if not given.
+	book := &books.Book{BookStatus: foundedBook.BookStatus}
+	readBookInput(book, p.Args["input"].(map[string]interface{}))
+
+	// Set initialized default data for book, the owner can not be changed:
+	book.ID = foundedBook.ID
+	book.CreatedAt = foundedBook.CreatedAt
+	book.UserID = foundedBook.UserID
+	book.UpdatedAt = time.Now()
+	book.Version = foundedBook.Version
+
+	// Validate status change and book fields like the REST handler.
+	if err := booksapi.ValidateBookUpdate(book, &foundedBook); err != nil {
+		return nil, newResolverError(err)
+	}
+
+	// Update book by given ID.
+	if err := h.db.UpdateBook(foundedBook.ID, book, caller.UserID); err != nil {
+		if errors.Is(err, books.ErrVersionConflict) {
+			return nil, newResolverError(problem.New(fiber.StatusPreconditionFailed, err.Error()))
+		}
+		return nil, newResolverError(err)
+	}
+
+	// Delete the cover, if the picture no longer points to it.
+	booksapi.DeleteReplacedCover(p.Context, h.blobs, &foundedBook, book)
+
+	return book, nil
+}
+
+// listParams func for reading pagination, filter and sort arguments
+// like the query params of the REST listing.
+func listParams(args map[string]interface{}) (books.BookListParams, error) {
+	params := books.BookListParams{
+		Limit: viper.GetInt("API_PAGINATION_DEFAULT_LIMIT"),
+	}
+
+	// Pagination.
+	if limit, ok := args["limit"].(int); ok {
+		params.Limit = limit
+	}
+	if params.Limit < 1 || params.Limit > viper.GetInt("API_PAGINATION_MAX_LIMIT") {
+		return params, fmt.Errorf("limit must be between 1 and %d", viper.GetInt("API_PAGINATION_MAX_LIMIT"))
+	}
+	if offset, ok := args["offset"].(int); ok {
+		params.Offset = offset
+	}
+	if params.Offset < 0 {
+		return params, fmt.Errorf("offset must not be negative")
+	}
+
+	// Filters.
+	filter, _ := args["filter"].(map[string]interface{})
+	if userID, ok := filter["userId"].(string); ok {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return params, fmt.Errorf("userId must be a UUID")
+		}
+		params.Filter.UserID = &id
+	}
+	params.Filter.Author, _ = filter["author"].(string)
+	params.Filter.Title, _ = filter["title"].(string)
+	if status, ok := filter["bookStatus"].(books.Status); ok {
+		params.Filter.BookStatus = &status
+	}
+	params.Filter.RatingMin = intArg(filter, "ratingMin")
+	params.Filter.RatingMax = intArg(filter, "ratingMax")
+	params.Filter.CreatedFrom = timeArg(filter, "createdFrom")
+	params.Filter.CreatedTo = timeArg(filter, "createdTo")
+	params.Filter.UpdatedFrom = timeArg(filter, "updatedFrom")
+	params.Filter.UpdatedTo = timeArg(filter, "updatedTo")
+
+	// Sorting.
+	sort, _ := args["sort"].(string)
+	var err error
+	if params.Sort, err = books.ParseSort(strings.TrimSpace(sort)); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// readBookInput func for setting the given fields of a book.
+func readBookInput(book *books.Book, input map[string]interface{}) {
+	book.Title, _ = input["title"].(string)
+	book.Author, _ = input["author"].(string)
+	if status, ok := input["bookStatus"].(books.Status); ok {
+		book.BookStatus = status
+	}
+	if attrs, ok := input["bookAttrs"].(map[string]interface{}); ok {
+		book.BookAttrs.Picture, _ = attrs["picture"].(string)
+		book.BookAttrs.Description, _ = attrs["description"].(string)
+		book.BookAttrs.Rating, _ = attrs["rating"].(int)
+	}
+}
+
+// intArg func for reading an optional integer argument.
+func intArg(args map[string]interface{}, key string) *int {
+	if n, ok := args[key].(int); ok {
+		return &n
+	}
+	return nil
+}
+
+// timeArg func for reading an optional date and time argument.
+func timeArg(args map[string]interface{}, key string) *time.Time {
+	if t, ok := args[key].(time.Time); ok {
+		return &t
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"fiber-api-example/app/utils/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// principalKey is the context key of the authenticated caller.
+type principalKey struct{}
+
+// UnaryAuthenticator func for creating the interceptor validating the bearer
+// access token of the "authorization" metadata like the JWT middleware.
+// Calls without token are passed on unauthenticated, services check the caller.
+func UnaryAuthenticator(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return handler(ctx, req)
+		}
+
+		header := values[0]
+		if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "missing or malformed JWT")
+		}
+
+		claims, err := authenticator.Authenticate(strings.TrimSpace(header[7:]))
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err != nil {
+			return nil, statusError(err)
+		}
+		principal, _ := claims.Principal()
+
+		return handler(context.WithValue(ctx, principalKey{}, principal), req)
+	}
+}
+
+// principal func for getting the authenticated caller of a call.
+// It returns an Unauthenticated error, if the call is not authenticated.
+func principal(ctx context.Context) (auth.Principal, error) {
+	p, ok := ctx.Value(principalKey{}).(auth.Principal)
+	if !ok {
+		return p, status.Error(codes.Unauthenticated, "missing or malformed JWT")
+	}
+	return p, nil
+}
@@ -0,0 +1,502 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fiber-api-example/app/api/books"
+	"fiber-api-example/app/api/grpc/bookspb"
+	"fiber-api-example/app/models"
+	bookmodels "fiber-api-example/app/models/books"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// BookServer struct for the gRPC book service with its dependencies.
+// It mirrors the REST book handlers and shares their validation.
+type BookServer struct {
+	bookspb.UnimplementedBookServiceServer
+
+	db    *database.Queries
+	blobs storage.BlobStore
+}
+
+// NewBookServer func for creating the book service using the given database
+// and the store of covers and files.
+func NewBookServer(db *database.Queries, blobs storage.BlobStore) *BookServer {
+	return &BookServer{db: db, blobs: blobs}
+}
+
+// ListBooks method gets a page of books matching the given filter.
+func (s *BookServer) ListBooks(_ context.Context, req *bookspb.ListBooksRequest) (*bookspb.ListBooksResponse, error) {
+	// Read pagination, filter and sort params.
+	params, err := listParams(req)
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	return s.listBooks(params)
+}
+
+// GetBook method gets book by given ID.
+func (s *BookServer) GetBook(_ context.Context, req *bookspb.GetBookRequest) (*bookspb.Book, error) {
+	id, err := parseID(req.GetId(), "book ID")
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	// Get book by ID.
+	book, err := s.db.GetBook(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, statusError(problem.New(fiber.StatusNotFound, "book with the given ID is not found"))
+		}
+		return nil, statusError(err)
+	}
+
+	return toBook(&book), nil
+}
+
+// CreateBook method creates a new book owned by the caller.
+func (s *BookServer) CreateBook(ctx context.Context, req *bookspb.CreateBookRequest) (*bookspb.Book, error) {
+	caller, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read fields of the book, new books are drafts, if no status is given.
+	status, _, err := fromStatus(req.GetBookStatus())
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+	book := &bookmodels.Book{
+		Title:      req.GetTitle(),
+		Author:     req.GetAuthor(),
+		BookStatus: status,
+		BookAttrs:  fromAttrs(req.GetBookAttrs()),
+	}
+
+	// Set initialized default data for book:
+	book.ID = uuid.New()
+	book.CreatedAt = time.Now()
+	book.UpdatedAt = book.CreatedAt
+	book.Version = 1
+	book.UserID = caller.UserID
+	book.StatusChangedAt = &book.CreatedAt
+
+	// Validate book fields like the REST handler.
+	if err := books.ValidateNewBook(book); err != nil {
+		return nil, statusError(err)
+	}
+
+	// Checking, if owner of the book is exists.
+	if _, err := s.db.GetUser(book.UserID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, statusError(problem.New(fiber.StatusUnauthorized, "user of the token is not found"))
+		}
+		return nil, statusError(err)
+	}
+
+	// Create book.
+	if err := s.db.CreateBook(book, caller.UserID); err != nil {
+		return nil, statusError(err)
+	}
+
+	return toBook(book), nil
+}
+
+// UpdateBook method replaces the fields of book by given ID.
+func (s *BookServer) UpdateBook(ctx context.Context, req *bookspb.UpdateBookRequest) (*bookspb.Book, error) {
+	caller, foundedBook, err := s.modifiableBook(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkVersion(req.Version, &foundedBook)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	// Read fields of the book, keeping the status, if not given.
+	status, ok, err := fromStatus(req.GetBookStatus())
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+	if !ok {
+		status = foundedBook.BookStatus
+	}
+	book := &bookmodels.Book{
+		Title:      req.GetTitle(),
+		Author:     req.GetAuthor(),
+		BookStatus: status,
+		BookAttrs:  fromAttrs(req.GetBookAttrs()),
+	}
+
+	// Set initialized default data for book, the owner can not be changed:
+	book.ID = foundedBook.ID
+	book.CreatedAt = foundedBook.CreatedAt
+	book.UserID = foundedBook.UserID
+	book.UpdatedAt = time.Now()
+	book.Version = version
+
+	// Validate status change and book fields like the REST handler.
+	if err := books.ValidateBookUpdate(book, &foundedBook); err != nil {
+		return nil, statusError(err)
+	}
+
+	// Update book by given ID.
+	if err := s.db.UpdateBook(foundedBook.ID, book, caller.UserID); err != nil {
+		return nil, statusError(versionError(err))
+	}
+
+	// Delete the cover, if the picture no longer points to it.
+	books.DeleteReplacedCover(ctx, s.blobs, &foundedBook, book)
+
+	return toBook(book), nil
+}
+
+// DeleteBook method moves book by given ID to the trash.
+func (s *BookServer) DeleteBook(ctx context.Context, req *bookspb.DeleteBookRequest) (*emptypb.Empty, error) {
+	caller, foundedBook, err := s.modifiableBook(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkVersion(req.Version, &foundedBook)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	// Delete book by given ID.
+	if err := s.db.DeleteBook(foundedBook.ID, version, caller.UserID); err != nil {
+		return nil, statusError(versionError(err))
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// TransitionBook method changes the status of book by given ID with the given transition.
+func (s *BookServer) TransitionBook(ctx context.Context, req *bookspb.TransitionBookRequest) (*bookspb.Book, error) {
+	// Find the requested transition.
+	transition, ok := bookmodels.FindTransition(req.GetTransition())
+	if !ok {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, "transition must be one of publish, unpublish, archive or withdraw"))
+	}
+
+	caller, foundedBook, err := s.modifiableBook(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Checking, if book was not changed since the client read it.
+	version, err := checkVersion(req.Version, &foundedBook)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	// Change status of the book.
+	book := foundedBook
+	book.UpdatedAt = time.Now()
+	book.Version = version
+	if err := transition.Apply(&book, book.UpdatedAt); err != nil {
+		return nil, statusError(problem.New(fiber.StatusConflict, err.Error()))
+	}
+
+	// Record status change of book by given ID.
+	if err := s.db.TransitionBook(foundedBook.ID, &book, transition.Name, caller.UserID); err != nil {
+		return nil, statusError(versionError(err))
+	}
+
+	return toBook(&book), nil
+}
+
+// ListDeletedBooks method gets a page of books in the trash.
+func (s *BookServer) ListDeletedBooks(ctx context.Context, req *bookspb.ListBooksRequest) (*bookspb.ListBooksResponse, error) {
+	caller, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read pagination, filter and sort params.
+	params, err := listParams(req)
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+	params.Filter.Deleted = true
+
+	// Restrict listing to own books, if caller may not see the whole trash.
+	if !policy.CanListAllDeletedBooks(caller) {
+		params.Filter.UserID = &caller.UserID
+	}
+
+	return s.listBooks(params)
+}
+
+// RestoreBook method restores book by given ID from the trash.
+func (s *BookServer) RestoreBook(ctx context.Context, req *bookspb.RestoreBookRequest) (*bookspb.Book, error) {
+	caller, foundedBook, err := s.deletedBook(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore book by given ID.
+	if err := s.db.RestoreBook(foundedBook.ID, caller.UserID); err != nil {
+		return nil, statusError(err)
+	}
+
+	// Get restored book.
+	book, err := s.db.GetBook(foundedBook.ID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toBook(&book), nil
+}
+
+// PurgeBook method permanently deletes book by given ID from the trash.
+func (s *BookServer) PurgeBook(ctx context.Context, req *bookspb.PurgeBookRequest) (*emptypb.Empty, error) {
+	_, foundedBook, err := s.deletedBook(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Purge book by given ID.
+	if err := s.db.PurgeBook(foundedBook.ID); err != nil {
+		return nil, statusError(err)
+	}
+
+	// Delete cover and files of the purged book, leftovers are only logged.
+	if err := storage.DeleteBookBlobs(ctx, s.blobs, foundedBook.ID); err != nil {
+		logger.Error("Can't delete blobs of purged book "+foundedBook.ID.String()+": ", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListBookRevisions method gets a page of revisions of book by given ID,
+// live or in the trash.
+func (s *BookServer) ListBookRevisions(ctx context.Context, req *bookspb.ListBookRevisionsRequest) (*bookspb.ListBookRevisionsResponse, error) {
+	caller, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(req.GetBookId(), "book ID")
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	// Read pagination params.
+	limit, offset, err := pagination(req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	// Checking, if book with given ID is exists.
+	book, err := s.db.GetBook(id)
+	if errors.Is(err, models.ErrNotFound) {
+		book, err = s.db.GetDeletedBook(id)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, statusError(problem.New(fiber.StatusNotFound, "book with this ID not found"))
+		}
+		return nil, statusError(err)
+	}
+
+	// Checking, if caller may see the history of the book.
+	if !policy.CanModifyBook(caller, &book) {
+		return nil, statusError(problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may see its revisions"))
+	}
+
+	// Get a page of revisions.
+	revisions, total, err := s.db.GetBookRevisions(book.ID, limit, offset)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &bookspb.ListBookRevisionsResponse{
+		Total:  int32(total),
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	}
+	for i := range revisions {
+		resp.Revisions = append(resp.Revisions, toRevision(&revisions[i]))
+	}
+	return resp, nil
+}
+
+// listBooks method gets a page of books.
+func (s *BookServer) listBooks(params bookmodels.BookListParams) (*bookspb.ListBooksResponse, error) {
+	list, total, err := s.db.GetBooks(params)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &bookspb.ListBooksResponse{
+		Books:  toBooks(list),
+		Total:  int32(total),
+		Limit:  int32(params.Limit),
+		Offset: int32(params.Offset),
+	}, nil
+}
+
+// modifiableBook method for getting the caller and the live book by given ID,
+// which the caller may change.
+func (s *BookServer) modifiableBook(ctx context.Context, bookID string) (caller auth.Principal, book bookmodels.Book, err error) {
+	if caller, err = principal(ctx); err != nil {
+		return caller, book, err
+	}
+
+	id, err := parseID(bookID, "book ID")
+	if err != nil {
+		return caller, book, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	// Checking, if book with given ID is exists.
+	if book, err = s.db.GetBook(id); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return caller, book, statusError(problem.New(fiber.StatusNotFound, "book with this ID not found"))
+		}
+		return caller, book, statusError(err)
+	}
+
+	// Checking, if caller may change the book.
+	if !policy.CanModifyBook(caller, &book) {
+		return caller, book, statusError(problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it"))
+	}
+
+	return caller, book, nil
+}
+
+// deletedBook method for getting the caller and the book in the trash by given ID,
+// which the caller may change.
+func (s *BookServer) deletedBook(ctx context.Context, bookID string) (caller auth.Principal, book bookmodels.Book, err error) {
+	if caller, err = principal(ctx); err != nil {
+		return caller, book, err
+	}
+
+	id, err := parseID(bookID, "book ID")
+	if err != nil {
+		return caller, book, statusError(problem.New(fiber.StatusBadRequest, err.Error()))
+	}
+
+	// Checking, if book with given ID is in the trash.
+	if book, err = s.db.GetDeletedBook(id); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return caller, book, statusError(problem.New(fiber.StatusNotFound, "book with this ID not found in the trash"))
+		}
+		return caller, book, statusError(err)
+	}
+
+	// Checking, if caller may change the book.
+	if !policy.CanModifyBook(caller, &book) {
+		return caller, book, statusError(problem.New(fiber.StatusForbidden, "only the owner of the book or an admin may change it"))
+	}
+
+	return caller, book, nil
+}
+
+// checkVersion func for checking the version a write is based on against
+// the stored book like the If-Match header. It returns the version the write
+// must be based on or a 412 or 428 problem, if the precondition is not met.
+func checkVersion(version *int32, b *bookmodels.Book) (int, error) {
+	if version == nil {
+		if viper.GetBool("API_REQUIRE_IF_MATCH") {
+			return 0, problem.New(fiber.StatusPreconditionRequired, "version is required")
+		}
+		return b.Version, nil
+	}
+
+	if int(*version) != b.Version {
+		return 0, problem.New(fiber.StatusPreconditionFailed, bookmodels.ErrVersionConflict.Error())
+	}
+	return b.Version, nil
+}
+
+// versionError func for mapping a version conflict of a write to a 412 problem.
+func versionError(err error) error {
+	if errors.Is(err, bookmodels.ErrVersionConflict) {
+		return problem.New(fiber.StatusPreconditionFailed, err.Error())
+	}
+	return err
+}
+
+// pagination func for reading the page size and offset of a listing,
+// a zero limit selects the default page size.
+func pagination(limit, offset int32) (int, int, error) {
+	if limit == 0 {
+		limit = int32(viper.GetInt("API_PAGINATION_DEFAULT_LIMIT"))
+	}
+	if limit < 1 || int(limit) > viper.GetInt("API_PAGINATION_MAX_LIMIT") {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", viper.GetInt("API_PAGINATION_MAX_LIMIT"))
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset must not be negative")
+	}
+	return int(limit), int(offset), nil
+}
+
+// listParams func for reading pagination, filter and sort fields
+// like the query params of the REST listing.
+func listParams(req *bookspb.ListBooksRequest) (bookmodels.BookListParams, error) {
+	params := bookmodels.BookListParams{}
+
+	var err error
+
+	// Pagination.
+	if params.Limit, params.Offset, err = pagination(req.GetLimit(), req.GetOffset()); err != nil {
+		return params, err
+	}
+
+	// Filters.
+	filter := req.GetFilter()
+	if filter.GetUserId() != "" {
+		id, err := parseID(filter.GetUserId(), "user_id")
+		if err != nil {
+			return params, err
+		}
+		params.Filter.UserID = &id
+	}
+	params.Filter.Author = filter.GetAuthor()
+	params.Filter.Title = filter.GetTitle()
+	status, ok, err := fromStatus(filter.GetBookStatus())
+	if err != nil {
+		return params, err
+	}
+	if ok {
+		params.Filter.BookStatus = &status
+	}
+	if filter != nil && filter.RatingMin != nil {
+		min := int(*filter.RatingMin)
+		params.Filter.RatingMin = &min
+	}
+	if filter != nil && filter.RatingMax != nil {
+		max := int(*filter.RatingMax)
+		params.Filter.RatingMax = &max
+	}
+	params.Filter.CreatedFrom = fromTimestamp(filter.GetCreatedFrom())
+	params.Filter.CreatedTo = fromTimestamp(filter.GetCreatedTo())
+	params.Filter.UpdatedFrom = fromTimestamp(filter.GetUpdatedFrom())
+	params.Filter.UpdatedTo = fromTimestamp(filter.GetUpdatedTo())
+
+	// Sorting.
+	if params.Sort, err = bookmodels.ParseSort(strings.TrimSpace(req.GetSort())); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
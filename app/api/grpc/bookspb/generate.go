@@ -0,0 +1,5 @@
+// Package bookspb contains the protobuf messages and the gRPC service of books
+// generated from books.proto.
+package bookspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative books.proto
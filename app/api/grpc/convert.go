@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"fiber-api-example/app/api/grpc/bookspb"
+	"fiber-api-example/app/models/books"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toBook func for converting a book into its message.
+func toBook(b *books.Book) *bookspb.Book {
+	return &bookspb.Book{
+		Id:              b.ID.String(),
+		CreatedAt:       toTimestamp(&b.CreatedAt),
+		UpdatedAt:       toTimestamp(&b.UpdatedAt),
+		DeletedAt:       toTimestamp(b.DeletedAt),
+		Version:         int32(b.Version),
+		UserId:          b.UserID.String(),
+		Title:           b.Title,
+		Author:          b.Author,
+		BookStatus:      toStatus(b.BookStatus),
+		StatusChangedAt: toTimestamp(b.StatusChangedAt),
+		BookAttrs: &bookspb.BookAttrs{
+			Picture:     b.BookAttrs.Picture,
+			Description: b.BookAttrs.Description,
+			Rating:      int32(b.BookAttrs.Rating),
+		},
+	}
+}
+
+// toBooks func for converting a page of books into messages.
+func toBooks(list []books.Book) []*bookspb.Book {
+	messages := make([]*bookspb.Book, len(list))
+	for i := range list {
+		messages[i] = toBook(&list[i])
+	}
+	return messages
+}
+
+// toRevision func for converting a revision into its message.
+func toRevision(r *books.Revision) *bookspb.Revision {
+	snapshot := books.Book(r.Book)
+	revision := &bookspb.Revision{
+		BookId:    r.BookID.String(),
+		Revision:  int32(r.Revision),
+		CreatedAt: toTimestamp(&r.CreatedAt),
+		Action:    r.Action,
+		Book:      toBook(&snapshot),
+	}
+	if r.UserID != nil {
+		revision.UserId = r.UserID.String()
+	}
+	return revision
+}
+
+// toStatus func for converting a book status into its enum value,
+// which are shifted by the unspecified value.
+func toStatus(s books.Status) bookspb.BookStatus {
+	return bookspb.BookStatus(s + 1)
+}
+
+// fromStatus func for converting an enum value into a book status.
+// The second value is false, if the status is unspecified.
+func fromStatus(s bookspb.BookStatus) (books.Status, bool, error) {
+	if s == bookspb.BookStatus_BOOK_STATUS_UNSPECIFIED {
+		return 0, false, nil
+	}
+	status := books.Status(s - 1)
+	if !status.IsValid() {
+		return 0, false, fmt.Errorf("book status %d is unknown", s)
+	}
+	return status, true, nil
+}
+
+// fromAttrs func for converting the attributes message of a book.
+func fromAttrs(attrs *bookspb.BookAttrs) books.BookAttrs {
+	return books.BookAttrs{
+		Picture:     attrs.GetPicture(),
+		Description: attrs.GetDescription(),
+		Rating:      int(attrs.GetRating()),
+	}
+}
+
+// toTimestamp func for converting a time, which is unset if nil or zero.
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// fromTimestamp func for converting an optional timestamp.
+func fromTimestamp(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := t.AsTime()
+	return &value
+}
+
+// parseID func for parsing the ID of a book or user.
+func parseID(id, name string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return parsed, fmt.Errorf("%s must be a UUID", name)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"sort"
+
+	"fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes maps the statuses of problems to gRPC status codes.
+var statusCodes = map[int]codes.Code{
+	fiber.StatusBadRequest:           codes.InvalidArgument,
+	fiber.StatusUnauthorized:         codes.Unauthenticated,
+	fiber.StatusForbidden:            codes.PermissionDenied,
+	fiber.StatusNotFound:             codes.NotFound,
+	fiber.StatusConflict:             codes.FailedPrecondition,
+	fiber.StatusPreconditionFailed:   codes.Aborted,
+	fiber.StatusPreconditionRequired: codes.FailedPrecondition,
+	fiber.StatusServiceUnavailable:   codes.Unavailable,
+}
+
+// statusError func for converting any error of a service into a gRPC status
+// like the error handler converts errors of handlers into problems.
+// Invalid fields are attached as BadRequest details, internal errors are
+// logged and their details hidden.
+func statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	p := problem.From(err)
+	code, ok := statusCodes[p.Status]
+	if !ok {
+		code = codes.Internal
+	}
+	if code == codes.Internal || code == codes.Unavailable {
+		logger.Error("gRPC call failed: ", err)
+	}
+
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	s := status.New(code, msg)
+
+	// Attach invalid fields in a stable order.
+	if len(p.Errors) > 0 {
+		details := &errdetails.BadRequest{}
+		for field, description := range p.Errors {
+			details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: description,
+			})
+		}
+		sort.Slice(details.FieldViolations, func(i, j int) bool {
+			return details.FieldViolations[i].Field < details.FieldViolations[j].Field
+		})
+		if detailed, err := s.WithDetails(details); err == nil {
+			s = detailed
+		}
+	}
+
+	return s.Err()
+}
@@ -1,11 +1,64 @@
 package api
 
 import (
+	"fiber-api-example/app/api/auth"
 	"fiber-api-example/app/api/books"
+	"fiber-api-example/app/api/graphql"
+	grpcapi "fiber-api-example/app/api/grpc"
+	"fiber-api-example/app/api/grpc/bookspb"
+	"fiber-api-example/app/api/users"
+	"fiber-api-example/app/api/webhooks"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/platform/events"
+	"fiber-api-example/app/platform/storage"
+	authenticator "fiber-api-example/app/utils/auth"
 	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 )
 
-func SetupRoutes(app *fiber.App) {
-	v1 := app.Group("/api/v1")
-	books.Routes(v1)
+func SetupRoutes(app *fiber.App, db *database.Queries, a *authenticator.Authenticator, stream *events.Broadcaster, blobs storage.BlobStore) {
+	authHandler := auth.NewHandler(db, a)
+	booksHandler := books.NewHandler(db, stream, blobs)
+	usersHandler := users.NewHandler(db)
+	webhooksHandler := webhooks.NewHandler(db)
+	graphqlHandler := graphql.NewHandler(db, blobs)
+
+	registry := NewRegistry(viper.GetString("API_DEFAULT_VERSION"))
+
+	// v1 reads the IDs of updated and deleted books from the body.
+	// Its book responses are frozen, see TestV1Contract of the books package.
+	registry.Register(Version{
+		Name:       "v1",
+		Deprecated: viper.GetTime("API_V1_DEPRECATED_AT"),
+		Sunset:     viper.GetTime("API_V1_SUNSET_AT"),
+		Routes: func(route fiber.Router) {
+			auth.Routes(route, authHandler)
+			books.Routes(route, booksHandler, a)
+			users.Routes(route, usersHandler, a)
+			webhooks.Routes(route, webhooksHandler, a)
+		},
+	})
+
+	// v2 reads all IDs from the path.
+	registry.Register(Version{
+		Name: "v2",
+		Routes: func(route fiber.Router) {
+			auth.Routes(route, authHandler)
+			books.RoutesV2(route, booksHandler, a)
+			users.Routes(route, usersHandler, a)
+			webhooks.Routes(route, webhooksHandler, a)
+		},
+	})
+
+	registry.Mount(app.Group("/api"))
+
+	// GraphQL is not versioned, its schema evolves by adding fields.
+	graphql.Routes(app, graphqlHandler, a)
+}
+
+// SetupGRPC func for registering the gRPC services, which share
+// the storage and validation of the REST API.
+func SetupGRPC(s grpc.ServiceRegistrar, db *database.Queries, blobs storage.BlobStore) {
+	bookspb.RegisterBookServiceServer(s, grpcapi.NewBookServer(db, blobs))
 }
@@ -0,0 +1,17 @@
+package users
+
+import (
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+func Routes(route fiber.Router, h *Handler, a *auth.Authenticator) {
+	route.Post("/users", h.NewUser)
+
+	// Routes for authenticated users:
+	route.Get("/users", middleware.Protected(a), h.GetUsers)
+	route.Get("/users/:id", middleware.Protected(a), h.GetUser)
+	route.Put("/users/:id", middleware.Protected(a), h.UpdateUser)
+	route.Delete("/users/:id", middleware.Protected(a), h.DeleteUser)
+}
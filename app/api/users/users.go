@@ -0,0 +1,327 @@
+package users
+
+import (
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/users"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"strconv"
+	"time"
+)
+
+// Handler struct for user handlers with their dependencies.
+type Handler struct {
+	db *database.Queries
+}
+
+// NewHandler func for creating user handlers using the given database.
+func NewHandler(db *database.Queries) *Handler {
+	return &Handler{db: db}
+}
+
+// GetUsers method gets a page of users.
+// @Description Get a page of users. Only admins may list users.
+// @Summary get users
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of users to skip"
+// @Success 200 {array} users.User
+// @Security ApiKeyAuth
+// @Router /v1/users [get]
+// @Router /v2/users [get]
+func (h *Handler) GetUsers(c *fiber.Ctx) error {
+	// Checking, if caller may list users.
+	if principal, _ := middleware.Principal(c); !policy.CanManageUsers(principal) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only admins may list users")
+	}
+
+	// Read pagination params.
+	limit, errLimit := strconv.Atoi(c.Query("limit", viper.GetString("API_PAGINATION_DEFAULT_LIMIT")))
+	offset, errOffset := strconv.Atoi(c.Query("offset", "0"))
+	if errLimit != nil || errOffset != nil ||
+		limit < 1 || limit > viper.GetInt("API_PAGINATION_MAX_LIMIT") || offset < 0 {
+		// Return status 400 and error message.
+		return problem.New(fiber.StatusBadRequest, "invalid limit or offset")
+	}
+
+	// Get a page of users.
+	users, total, err := h.db.GetUsers(limit, offset)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":  false,
+		"msg":    nil,
+		"count":  len(users),
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+		"users":  users,
+	})
+}
+
+// GetUser method gets user by given ID or 404 error.
+// @Description Get user by given ID.
+// @Summary get user by given ID
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} users.User
+// @Security ApiKeyAuth
+// @Router /v1/users/{id} [get]
+// @Router /v2/users/{id} [get]
+func (h *Handler) GetUser(c *fiber.Ctx) error {
+	// Catch user ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "user ID must be a UUID")
+	}
+
+	// Get user by ID.
+	user, err := h.db.GetUser(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "user with the given ID is not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may read the user.
+	if principal, _ := middleware.Principal(c); !policy.CanAccessUser(principal, &user) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "users may only read themselves")
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"user":  user,
+	})
+}
+
+// NewUser method for creates a new user.
+// @Description Create a new user.
+// @Summary create a new user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param email body string true "Email"
+// @Param password body string true "Password"
+// @Success 200 {object} users.User
+// @Router /v1/users [post]
+// @Router /v2/users [post]
+func (h *Handler) NewUser(c *fiber.Ctx) error {
+	// Create new Credentials struct
+	credentials := &users.Credentials{}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(credentials); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Create a new validator for a User model.
+	validate := utils.NewValidator()
+
+	// Validate credentials fields.
+	if err := validate.Struct(credentials); err != nil {
+		// Return, if some fields are not valid.
+		return problem.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Hash password with bcrypt.
+	passwordHash, err := auth.HashPassword(credentials.Password)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Set initialized default data for user:
+	user := &users.User{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		Email:        credentials.Email,
+		UserStatus:   1, // 0 == blocked, 1 == active
+		UserRole:     users.RoleUser,
+		PasswordHash: passwordHash,
+	}
+
+	// Create user.
+	if err := h.db.CreateUser(user); err != nil {
+		// Return status 409, if email is taken, or the storage error.
+		if errors.Is(err, models.ErrConflict) {
+			return problem.New(fiber.StatusConflict, "user with this email already exists")
+		}
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error": false,
+		"msg":   nil,
+		"user":  user,
+	})
+}
+
+// UpdateUser method for updates user by given ID.
+// @Description Update user.
+// @Summary update user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param email body string true "Email"
+// @Param user_status body integer false "User status, only admins may change it"
+// @Param user_role body string false "User role, only admins may change it"
+// @Success 201 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/users/{id} [put]
+// @Router /v2/users/{id} [put]
+func (h *Handler) UpdateUser(c *fiber.Ctx) error {
+	// Catch user ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "user ID must be a UUID")
+	}
+
+	// Checking, if user with given ID is exists.
+	foundedUser, err := h.db.GetUser(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "user with this ID not found")
+		}
+		return err
+	}
+
+	// Create new User struct, omitted role and status are kept.
+	user := &users.User{
+		UserRole:   foundedUser.UserRole,
+		UserStatus: foundedUser.UserStatus,
+	}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(user); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Checking, if caller may change the user, roles and statuses
+	// may only be changed by admins.
+	principal, _ := middleware.Principal(c)
+	if !policy.CanAccessUser(principal, &foundedUser) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "users may only change themselves")
+	}
+	if (user.UserRole != foundedUser.UserRole || user.UserStatus != foundedUser.UserStatus) &&
+		!policy.CanManageUsers(principal) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only admins may change roles and statuses")
+	}
+
+	// Set initialized default data for user:
+	user.ID = foundedUser.ID
+	user.UpdatedAt = time.Now()
+
+	// Create a new validator for a User model.
+	validate := utils.NewValidator()
+
+	// Validate user fields.
+	if err := validate.Struct(user); err != nil {
+		// Return, if some fields are not valid.
+		return problem.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Update user by given ID.
+	if err := h.db.UpdateUser(foundedUser.ID, user); err != nil {
+		// Return status 409, if email is taken, or the storage error.
+		if errors.Is(err, models.ErrConflict) {
+			return problem.New(fiber.StatusConflict, "user with this email already exists")
+		}
+		return err
+	}
+
+	// Return status 201.
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// DeleteUser method for deletes user by given ID.
+// @Description Delete user by given ID. Users owning books, even in the trash, can not be deleted.
+// @Summary delete user by given ID
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/users/{id} [delete]
+// @Router /v2/users/{id} [delete]
+func (h *Handler) DeleteUser(c *fiber.Ctx) error {
+	// Catch user ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "user ID must be a UUID")
+	}
+
+	// Checking, if user with given ID is exists.
+	foundedUser, err := h.db.GetUser(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "user with this ID not found")
+		}
+		return err
+	}
+
+	// Checking, if caller may delete the user.
+	if principal, _ := middleware.Principal(c); !policy.CanAccessUser(principal, &foundedUser) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "users may only delete themselves")
+	}
+
+	// Checking, if user still owns books, including books in the trash.
+	for _, deleted := range []bool{false, true} {
+		_, total, err := h.db.GetBooks(books.BookListParams{
+			Limit:  1,
+			Filter: books.BookFilter{UserID: &foundedUser.ID, Deleted: deleted},
+		})
+		if err != nil {
+			// Return error, the error handler maps it to its status.
+			return err
+		}
+		if total > 0 {
+			// Return status 409 and conflict error.
+			return problem.New(fiber.StatusConflict, "user still owns books")
+		}
+	}
+
+	// Delete user by given ID.
+	if err := h.db.DeleteUser(foundedUser.ID); err != nil {
+		// Return status 409, if books were added meanwhile, or the storage error.
+		if errors.Is(err, models.ErrConflict) {
+			return problem.New(fiber.StatusConflict, "user still owns books")
+		}
+		return err
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of API versioning.
+const (
+	// HeaderAcceptVersion selects the version of requests without one in the path.
+	HeaderAcceptVersion = "Accept-Version"
+
+	// HeaderAPIVersion tells the version, which handled the request.
+	HeaderAPIVersion = "API-Version"
+
+	// HeaderDeprecation and HeaderSunset announce the deprecation (RFC 9745)
+	// and the removal (RFC 8594) of a version.
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+)
+
+// Version struct to describe a version of the API mounted under /api/<name>.
+type Version struct {
+	// Name is the path segment of the version, e.g. "v1".
+	Name string
+
+	// Deprecated and Sunset are the times the version was deprecated
+	// and will be removed, zero if not planned.
+	Deprecated time.Time
+	Sunset     time.Time
+
+	// Routes registers the routes of the version.
+	Routes func(route fiber.Router)
+}
+
+// Registry struct for mounting versions of the API side by side.
+type Registry struct {
+	versions       []Version
+	defaultVersion string
+}
+
+// NewRegistry func for creating a registry, requests without a version
+// are handled by the default version.
+func NewRegistry(defaultVersion string) *Registry {
+	return &Registry{defaultVersion: defaultVersion}
+}
+
+// Register method for adding a version of the API.
+func (r *Registry) Register(v Version) {
+	r.versions = append(r.versions, v)
+}
+
+// Mount method for registering the routes of all versions under the given router.
+// The version is selected by the path, e.g. /api/v1/books, or for paths
+// without one, e.g. /api/books, by the Accept-Version header.
+func (r *Registry) Mount(route fiber.Router) {
+	route.Use(r.selectVersion)
+
+	for _, v := range r.versions {
+		v.Routes(route.Group("/"+v.Name, v.headers))
+	}
+}
+
+// selectVersion rewrites paths without a version to the requested one.
+func (r *Registry) selectVersion(c *fiber.Ctx) error {
+	// Split "/api/v1/books" into prefix "/api" and rest "/v1/books".
+	prefix := strings.TrimSuffix(c.Route().Path, "/")
+	rest := strings.TrimPrefix(c.Path(), prefix)
+
+	// Keep versions given in the path.
+	segment := strings.SplitN(strings.TrimPrefix(rest, "/"), "/", 2)[0]
+	if _, ok := r.find(segment); ok {
+		return c.Next()
+	}
+
+	// Select version by header or default.
+	name := r.defaultVersion
+	if requested := c.Get(HeaderAcceptVersion); requested != "" {
+		v, ok := r.find(requested)
+		if !ok {
+			// Return status 400 and supported versions.
+			return problem.New(fiber.StatusBadRequest, "API version must be one of "+strings.Join(r.names(), ", "))
+		}
+		name = v.Name
+	}
+
+	c.Vary(HeaderAcceptVersion)
+	c.Path(prefix + "/" + name + rest)
+	return c.Next()
+}
+
+// find returns the version with the given name, the leading "v" may be omitted.
+func (r *Registry) find(name string) (Version, bool) {
+	name = strings.ToLower(name)
+	for _, v := range r.versions {
+		if v.Name == name || v.Name == "v"+name {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// names returns the names of all versions.
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.versions))
+	for _, v := range r.versions {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// headers sets the version headers of responses, deprecated versions
+// announce their deprecation and removal.
+func (v Version) headers(c *fiber.Ctx) error {
+	c.Set(HeaderAPIVersion, v.Name)
+	if !v.Deprecated.IsZero() {
+		c.Set(HeaderDeprecation, "@"+strconv.FormatInt(v.Deprecated.Unix(), 10))
+	}
+	if !v.Sunset.IsZero() {
+		c.Set(HeaderSunset, v.Sunset.UTC().Format(http.TimeFormat))
+	}
+	return c.Next()
+}
@@ -0,0 +1,19 @@
+package webhooks
+
+import (
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+func Routes(route fiber.Router, h *Handler, a *auth.Authenticator) {
+	// Routes for admins:
+	route.Get("/webhooks", middleware.Protected(a), h.GetWebhooks)
+	route.Post("/webhooks", middleware.Protected(a), h.NewWebhook)
+	route.Get("/webhooks/:id", middleware.Protected(a), h.GetWebhook)
+	route.Put("/webhooks/:id", middleware.Protected(a), h.UpdateWebhook)
+	route.Delete("/webhooks/:id", middleware.Protected(a), h.DeleteWebhook)
+	route.Get("/webhooks/:id/deliveries", middleware.Protected(a), h.GetWebhookDeliveries)
+	route.Get("/webhooks/:id/deliveries/:delivery_id", middleware.Protected(a), h.GetWebhookDelivery)
+	route.Post("/webhooks/:id/deliveries/:delivery_id/retry", middleware.Protected(a), h.RetryWebhookDelivery)
+}
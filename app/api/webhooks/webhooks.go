@@ -0,0 +1,514 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/webhooks"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/policy"
+	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Handler struct for webhook handlers with their dependencies.
+type Handler struct {
+	db *database.Queries
+}
+
+// NewHandler func for creating webhook handlers using the given database.
+func NewHandler(db *database.Queries) *Handler {
+	return &Handler{db: db}
+}
+
+// GetWebhooks method gets a page of webhook endpoints.
+// @Description Get a page of webhook endpoints. Only admins may manage webhooks.
+// @Summary get webhook endpoints
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of endpoints to skip"
+// @Success 200 {array} webhooks.Endpoint
+// @Security ApiKeyAuth
+// @Router /v1/webhooks [get]
+// @Router /v2/webhooks [get]
+func (h *Handler) GetWebhooks(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Read pagination params.
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+
+	// Get a page of endpoints.
+	endpoints, total, err := h.db.GetWebhookEndpoints(limit, offset)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Hide secrets of endpoints.
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":     false,
+		"msg":       nil,
+		"count":     len(endpoints),
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+		"endpoints": endpoints,
+	})
+}
+
+// GetWebhook method gets webhook endpoint by given ID or 404 error.
+// @Description Get webhook endpoint by given ID. The secret is only returned on creation.
+// @Summary get webhook endpoint by given ID
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 200 {object} webhooks.Endpoint
+// @Security ApiKeyAuth
+// @Router /v1/webhooks/{id} [get]
+// @Router /v2/webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Get endpoint by ID from URL.
+	endpoint, err := h.endpoint(c)
+	if err != nil {
+		return err
+	}
+	endpoint.Secret = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"msg":      nil,
+		"endpoint": endpoint,
+	})
+}
+
+// NewWebhook method for creates a new webhook endpoint.
+// @Description Create a new webhook endpoint. Events are POSTed to the URL with a Webhook-Signature header "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>" with the secret>". A secret is generated, if not given, and only returned here.
+// @Summary create a new webhook endpoint
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param url body string true "URL receiving the events"
+// @Param secret body string false "Secret signing the deliveries"
+// @Param events body []string false "Event types, all if empty: book.created, book.updated, book.status_changed, book.deleted, book.restored, book.purged"
+// @Param description body string false "Description"
+// @Param active body boolean false "Active, true if not given"
+// @Success 200 {object} webhooks.Endpoint
+// @Security ApiKeyAuth
+// @Router /v1/webhooks [post]
+// @Router /v2/webhooks [post]
+func (h *Handler) NewWebhook(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Create new Endpoint struct, active if not given.
+	endpoint := &webhooks.Endpoint{Active: true}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(endpoint); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Generate secret, if not given.
+	if endpoint.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			// Return error, the error handler maps it to its status.
+			return err
+		}
+		endpoint.Secret = secret
+	}
+
+	// Set initialized default data for endpoint:
+	principal, _ := middleware.Principal(c)
+	endpoint.ID = uuid.New()
+	endpoint.CreatedAt = time.Now()
+	endpoint.UpdatedAt = endpoint.CreatedAt
+	endpoint.UserID = &principal.UserID
+
+	// Validate endpoint fields.
+	if err := validate(endpoint); err != nil {
+		return err
+	}
+
+	// Create endpoint.
+	if err := h.db.CreateWebhookEndpoint(endpoint); err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"msg":      nil,
+		"endpoint": endpoint,
+	})
+}
+
+// UpdateWebhook method for updates webhook endpoint by given ID.
+// @Description Update webhook endpoint. The secret is kept, if not given.
+// @Summary update webhook endpoint
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param url body string true "URL receiving the events"
+// @Param secret body string false "New secret signing the deliveries"
+// @Param events body []string false "Event types, all if empty: book.created, book.updated, book.status_changed, book.deleted, book.restored, book.purged"
+// @Param description body string false "Description"
+// @Param active body boolean false "Active, true if not given"
+// @Success 201 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/webhooks/{id} [put]
+// @Router /v2/webhooks/{id} [put]
+func (h *Handler) UpdateWebhook(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Checking, if endpoint with given ID is exists.
+	foundedEndpoint, err := h.endpoint(c)
+	if err != nil {
+		return err
+	}
+
+	// Create new Endpoint struct, active if not given.
+	endpoint := &webhooks.Endpoint{Active: true}
+
+	// Check, if received JSON data is valid.
+	if err := c.BodyParser(endpoint); err != nil {
+		// Return status 400 and error message.
+		return problem.Wrap(fiber.StatusBadRequest, err, "request body is not valid JSON")
+	}
+
+	// Set initialized default data for endpoint:
+	endpoint.ID = foundedEndpoint.ID
+	endpoint.CreatedAt = foundedEndpoint.CreatedAt
+	endpoint.UpdatedAt = time.Now()
+	endpoint.UserID = foundedEndpoint.UserID
+	if endpoint.Secret == "" {
+		endpoint.Secret = foundedEndpoint.Secret
+	}
+
+	// Validate endpoint fields.
+	if err := validate(endpoint); err != nil {
+		return err
+	}
+
+	// Update endpoint by given ID.
+	if err := h.db.UpdateWebhookEndpoint(foundedEndpoint.ID, endpoint); err != nil {
+		// Return status 404, if deleted meanwhile, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "webhook endpoint with this ID not found")
+		}
+		return err
+	}
+
+	// Return status 201.
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// DeleteWebhook method for deletes webhook endpoint by given ID.
+// @Description Delete webhook endpoint by given ID with its deliveries.
+// @Summary delete webhook endpoint by given ID
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 204 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/webhooks/{id} [delete]
+// @Router /v2/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Catch endpoint ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return problem.Wrap(fiber.StatusBadRequest, err, "webhook endpoint ID must be a UUID")
+	}
+
+	// Delete endpoint by given ID.
+	if err := h.db.DeleteWebhookEndpoint(id); err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return problem.New(fiber.StatusNotFound, "webhook endpoint with this ID not found")
+		}
+		return err
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetWebhookDeliveries method gets a page of deliveries to a webhook endpoint.
+// @Description Get a page of deliveries to a webhook endpoint, the latest first.
+// @Summary get deliveries of webhook endpoint
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param limit query integer false "Page size"
+// @Param offset query integer false "Number of deliveries to skip"
+// @Success 200 {array} webhooks.Delivery
+// @Security ApiKeyAuth
+// @Router /v1/webhooks/{id}/deliveries [get]
+// @Router /v2/webhooks/{id}/deliveries [get]
+func (h *Handler) GetWebhookDeliveries(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Checking, if endpoint with given ID is exists.
+	endpoint, err := h.endpoint(c)
+	if err != nil {
+		return err
+	}
+
+	// Read pagination params.
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+
+	// Get a page of deliveries.
+	deliveries, total, err := h.db.GetWebhookDeliveries(endpoint.ID, limit, offset)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":      false,
+		"msg":        nil,
+		"count":      len(deliveries),
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+		"deliveries": deliveries,
+	})
+}
+
+// GetWebhookDelivery method gets a delivery with its attempts or 404 error.
+// @Description Get a delivery to a webhook endpoint with all attempts to send it, including the responses.
+// @Summary get webhook delivery with its attempts
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} webhooks.Delivery
+// @Security ApiKeyAuth
+// @Router /v1/webhooks/{id}/deliveries/{delivery_id} [get]
+// @Router /v2/webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *Handler) GetWebhookDelivery(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Get delivery by IDs from URL.
+	delivery, err := h.delivery(c)
+	if err != nil {
+		return err
+	}
+
+	// Get attempts of the delivery.
+	attempts, err := h.db.GetWebhookAttempts(delivery.ID)
+	if err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"msg":      nil,
+		"delivery": delivery,
+		"attempts": attempts,
+	})
+}
+
+// RetryWebhookDelivery method for sends a delivery again.
+// @Description Make a delivery pending again, e.g. a dead one. It is sent with the next check for due deliveries and gets all retries again.
+// @Summary retry webhook delivery
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 202 {string} status "ok"
+// @Security ApiKeyAuth
+// @Router /v1/webhooks/{id}/deliveries/{delivery_id}/retry [post]
+// @Router /v2/webhooks/{id}/deliveries/{delivery_id}/retry [post]
+func (h *Handler) RetryWebhookDelivery(c *fiber.Ctx) error {
+	// Checking, if caller may manage webhooks.
+	if err := authorize(c); err != nil {
+		return err
+	}
+
+	// Checking, if delivery with given IDs is exists.
+	delivery, err := h.delivery(c)
+	if err != nil {
+		return err
+	}
+
+	// Checking, if delivery is not being sent.
+	if delivery.Status == webhooks.DeliveryPending {
+		// Return status 409 and conflict error.
+		return problem.New(fiber.StatusConflict, "delivery is already pending")
+	}
+
+	// Make delivery pending.
+	if err := h.db.RetryWebhookDelivery(delivery.EndpointID, delivery.ID); err != nil {
+		// Return error, the error handler maps it to its status.
+		return err
+	}
+
+	// Return status 202 accepted.
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// endpoint gets the endpoint by the ID in the URL.
+func (h *Handler) endpoint(c *fiber.Ctx) (webhooks.Endpoint, error) {
+	// Catch endpoint ID from URL.
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return webhooks.Endpoint{}, problem.Wrap(fiber.StatusBadRequest, err, "webhook endpoint ID must be a UUID")
+	}
+
+	// Get endpoint by ID.
+	endpoint, err := h.db.GetWebhookEndpoint(id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return endpoint, problem.New(fiber.StatusNotFound, "webhook endpoint with this ID not found")
+		}
+		return endpoint, err
+	}
+
+	return endpoint, nil
+}
+
+// delivery gets the delivery by the endpoint and delivery IDs in the URL.
+func (h *Handler) delivery(c *fiber.Ctx) (webhooks.Delivery, error) {
+	// Catch endpoint and delivery IDs from URL.
+	endpointID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return webhooks.Delivery{}, problem.Wrap(fiber.StatusBadRequest, err, "webhook endpoint ID must be a UUID")
+	}
+	id, err := uuid.Parse(c.Params("delivery_id"))
+	if err != nil {
+		return webhooks.Delivery{}, problem.Wrap(fiber.StatusBadRequest, err, "delivery ID must be a UUID")
+	}
+
+	// Get delivery by IDs.
+	delivery, err := h.db.GetWebhookDelivery(endpointID, id)
+	if err != nil {
+		// Return status 404, if not found, or the storage error.
+		if errors.Is(err, models.ErrNotFound) {
+			return delivery, problem.New(fiber.StatusNotFound, "delivery with this ID not found")
+		}
+		return delivery, err
+	}
+
+	return delivery, nil
+}
+
+// authorize checks, if the caller may manage webhooks.
+func authorize(c *fiber.Ctx) error {
+	if principal, _ := middleware.Principal(c); !policy.CanManageWebhooks(principal) {
+		// Return status 403 and forbidden error.
+		return problem.New(fiber.StatusForbidden, "only admins may manage webhooks")
+	}
+	return nil
+}
+
+// pagination reads the limit and offset query params.
+func pagination(c *fiber.Ctx) (int, int, error) {
+	limit, errLimit := strconv.Atoi(c.Query("limit", viper.GetString("API_PAGINATION_DEFAULT_LIMIT")))
+	offset, errOffset := strconv.Atoi(c.Query("offset", "0"))
+	if errLimit != nil || errOffset != nil ||
+		limit < 1 || limit > viper.GetInt("API_PAGINATION_MAX_LIMIT") || offset < 0 {
+		// Return status 400 and error message.
+		return 0, 0, problem.New(fiber.StatusBadRequest, "invalid limit or offset")
+	}
+	return limit, offset, nil
+}
+
+// validate checks the fields of the endpoint, the URL must be HTTP(S)
+// and the events must be known book event types.
+func validate(endpoint *webhooks.Endpoint) error {
+	// Validate endpoint fields.
+	if err := utils.NewValidator().Struct(endpoint); err != nil {
+		// Return, if some fields are not valid.
+		return problem.Validation(utils.ValidatorErrors(err))
+	}
+
+	if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return problem.Validation(map[string]string{"url": "webhook URL must be an HTTP or HTTPS URL"})
+	}
+	for _, t := range endpoint.Events {
+		if !knownEvent(t) {
+			return problem.Validation(map[string]string{"events": "unknown event type " + strconv.Quote(t)})
+		}
+	}
+
+	return nil
+}
+
+// knownEvent checks, if the type is a book event type.
+func knownEvent(eventType string) bool {
+	for _, t := range books.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// newSecret generates a random secret for signing deliveries.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fiber-api-example/app/utils/problem"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/viper"
@@ -30,19 +31,8 @@ func Init() {
 	viper.AutomaticEnv()
 }
 
-var defaultErrorHandler = func(ctx *fiber.Ctx, err error) error {
-	// Statuscode defaults to 500
-	code := fiber.StatusInternalServerError
-
-	// Check if it's an fiber.Error type
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-	}
-
-	// Return HTTP response
-	ctx.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
-	return ctx.Status(code).SendString(err.Error())
-}
+// defaultErrorHandler writes every error as application/problem+json.
+var defaultErrorHandler = problem.ErrorHandler
 
 func GetFiberConfig() fiber.Config {
 	return fiber.Config{
@@ -70,21 +60,146 @@ func GetFiberConfig() fiber.Config {
 		DisableHeaderNormalizing:  viper.GetBool("FIBER_DISABLEHEADERNORMALIZING"),
 		DisableStartupMessage:     viper.GetBool("FIBER_DISABLESTARTUPMESSAGE"),
 		ReduceMemoryUsage:         viper.GetBool("FIBER_REDUCEMEMORYUSAGE"),
+		// Bodies are streamed, so uploads are not held in memory. They are read
+		// up to FIBER_BODYLIMIT by the BodyLimit middleware, uploads up to the
+		// limit of their routes. Multipart forms are parsed by their handlers.
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	}
 }
 
+// UploadOverhead is the size allowed for the multipart form of an upload
+// on top of the size of the uploaded file.
+const UploadOverhead = 64 * 1024
+
+// UploadBodyLimit func for getting the body limit of requests uploading
+// a file up to the size of the given key, e.g. FILE_MAX_SIZE.
+func UploadBodyLimit(key string) int {
+	return viper.GetInt(key) + UploadOverhead
+}
+
 func setDefaults() {
 	// Set default App configuration
 	viper.SetDefault("APP_ADDR", ":8080")
 	viper.SetDefault("APP_ENV", "local")
 
 	// Set default database configuration
+	// DB_DRIVER is "postgres" or "memory"
 	viper.SetDefault("DB_DRIVER", "postgres")
 	viper.SetDefault("DB_HOST", "localhost")
 	viper.SetDefault("DB_USERNAME", "admin")
 	viper.SetDefault("DB_PASSWORD", "masterkey")
 	viper.SetDefault("DB_PORT", 5432)
 	viper.SetDefault("DB_DATABASE", "db")
+	viper.SetDefault("DB_MAX_OPEN_CONNS", 25)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", 25)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", "5m")
+	viper.SetDefault("DB_CONN_MAX_IDLE_TIME", "1m")
+	viper.SetDefault("DB_CONNECT_TIMEOUT", "1m")
+	viper.SetDefault("DB_CONNECT_BACKOFF", "500ms")
+	viper.SetDefault("DB_CONNECT_BACKOFF_MAX", "10s")
+	viper.SetDefault("DB_MIGRATE_ON_STARTUP", false)
+
+	// Set default JWT configuration
+	// JWT_ALGORITHM is used to sign issued tokens, HS256 or RS256
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
+	viper.SetDefault("JWT_SECRET", "")
+	viper.SetDefault("JWT_PUBLIC_KEY", "")
+	viper.SetDefault("JWT_PRIVATE_KEY", "")
+	viper.SetDefault("JWT_ISSUER", "fiber-api-example")
+	viper.SetDefault("JWT_AUDIENCE", "fiber-api-example")
+	viper.SetDefault("JWT_LEEWAY", "30s")
+	viper.SetDefault("JWT_ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("JWT_REFRESH_TOKEN_TTL", "720h")
+
+	// Set default API pagination configuration
+	viper.SetDefault("API_PAGINATION_DEFAULT_LIMIT", 20)
+	viper.SetDefault("API_PAGINATION_MAX_LIMIT", 100)
+
+	// Set default API concurrency configuration,
+	// book writes without If-Match are refused in strict mode
+	viper.SetDefault("API_REQUIRE_IF_MATCH", false)
+
+	// Set default API versioning configuration, requests without a version
+	// in the path or Accept-Version header are handled by the default version,
+	// empty times mean the deprecation or removal of v1 is not planned
+	viper.SetDefault("API_DEFAULT_VERSION", "v2")
+	viper.SetDefault("API_V1_DEPRECATED_AT", "")
+	viper.SetDefault("API_V1_SUNSET_AT", "")
+
+	// Set default idempotency configuration, responses of book writes
+	// with an Idempotency-Key are replayed to retries within the TTL,
+	// zero disables idempotency keys
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("IDEMPOTENCY_PURGE_INTERVAL", "1h")
+
+	// Set default trash configuration, deleted books are purged
+	// after the retention period, zero keeps them forever
+	viper.SetDefault("TRASH_RETENTION", "720h")
+	viper.SetDefault("TRASH_PURGE_INTERVAL", "1h")
+
+	// Set default webhook configuration, failed deliveries are retried
+	// with exponential backoff until they run out of attempts and become dead,
+	// zero poll interval disables sending deliveries
+	viper.SetDefault("WEBHOOK_POLL_INTERVAL", "5s")
+	viper.SetDefault("WEBHOOK_BATCH_SIZE", 50)
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_BACKOFF", "30s")
+	viper.SetDefault("WEBHOOK_BACKOFF_MAX", "6h")
+
+	// Set default outbox configuration, book events are published by the relay
+	// to webhooks and optionally to the log and an HTTP URL, failed messages
+	// are retried with exponential backoff until published, published ones
+	// are kept for the retention period, zero poll interval disables the relay
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	viper.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	viper.SetDefault("OUTBOX_PUBLISH_TIMEOUT", "10s")
+	viper.SetDefault("OUTBOX_BACKOFF", "1s")
+	viper.SetDefault("OUTBOX_BACKOFF_MAX", "5m")
+	viper.SetDefault("OUTBOX_RETENTION", "168h")
+	viper.SetDefault("OUTBOX_PUBLISH_LOG", false)
+	viper.SetDefault("OUTBOX_PUBLISH_HTTP_URL", "")
+	viper.SetDefault("OUTBOX_PUBLISH_HTTP_TIMEOUT", "10s")
+
+	// Set default stream configuration, the recent book events are kept
+	// for resuming clients, clients falling more events behind than the
+	// buffer are disconnected, idle streams send heartbeats, every instance
+	// reads new events from the outbox and waits for missing ones a while
+	viper.SetDefault("STREAM_HISTORY", 1000)
+	viper.SetDefault("STREAM_BUFFER", 256)
+	viper.SetDefault("STREAM_HEARTBEAT", "15s")
+	viper.SetDefault("STREAM_POLL_INTERVAL", "1s")
+	viper.SetDefault("STREAM_BATCH_SIZE", 100)
+	viper.SetDefault("STREAM_GAP_TIMEOUT", "5s")
+
+	// Set default GraphQL configuration, queries nesting fields deeper or
+	// resolving more fields, counting the items of pages, are rejected
+	viper.SetDefault("GRAPHQL_MAX_DEPTH", 15)
+	viper.SetDefault("GRAPHQL_MAX_COMPLEXITY", 2000)
+
+	// Set default gRPC configuration, the book service is served on its own
+	// address, calls still running after the shutdown timeout are cancelled,
+	// empty address disables the server
+	viper.SetDefault("GRPC_ADDR", ":50051")
+	viper.SetDefault("GRPC_SHUTDOWN_TIMEOUT", "10s")
+
+	// Set default blob storage configuration, uploaded files are kept
+	// below the root directory of the local driver
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_ROOT", "data/blobs")
+
+	// Set default book cover configuration, covers larger than the max size
+	// or with more pixels are rejected, thumbnails are generated in the
+	// comma-separated widths
+	viper.SetDefault("COVER_MAX_SIZE", 2097152)
+	viper.SetDefault("COVER_MAX_PIXELS", 25000000)
+	viper.SetDefault("COVER_THUMBNAIL_WIDTHS", "160,320,640")
+
+	// Set default e-book file configuration, larger files are rejected.
+	// Uploads of covers and files are not limited by FIBER_BODYLIMIT, their
+	// bodies are streamed up to the max size
+	viper.SetDefault("FILE_MAX_SIZE", 104857600)
 
 	//// Set default session configuration
 	//viper.SetDefault("SESSION_PROVIDER", "mysql")
@@ -134,6 +249,9 @@ func setDefaults() {
 	viper.SetDefault("FIBER_DISABLESTARTUPMESSAGE", false)
 	viper.SetDefault("FIBER_REDUCEMEMORYUSAGE", false)
 
+	// Set default JWT middleware configuration
+	viper.SetDefault("MW_JWT_CONTEXTKEY", "user")
+
 	// Set default Custom Access Logger middleware configuration
 	viper.SetDefault("MW_ACCESS_LOGGER_ENABLED", false)
 	viper.SetDefault("MW_ACCESS_LOGGER_TYPE", "console")
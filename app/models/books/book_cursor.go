@@ -0,0 +1,191 @@
+package books
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a cursor can not be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor struct to describe a position in a keyset paginated listing.
+// It holds the sort order of the listing and the sort key values of the
+// last returned book, followed by its ID.
+type Cursor struct {
+	Sort   []SortField `json:"s"`
+	Values []string    `json:"v"`
+}
+
+// NewCursor func for creating a cursor pointing after the given book.
+func NewCursor(sort []SortField, b *Book) *Cursor {
+	sort = normalizeSort(sort)
+
+	values := make([]string, 0, len(sort))
+	for _, s := range sort {
+		values = append(values, sortValue(s.Field, b))
+	}
+
+	return &Cursor{Sort: sort, Values: values}
+}
+
+// DecodeCursor func for decoding an opaque cursor string.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// Check, if cursor matches a normalized sort order.
+	if len(cursor.Sort) == 0 || len(cursor.Sort) != len(cursor.Values) ||
+		cursor.Sort[len(cursor.Sort)-1].Field != "id" {
+		return nil, ErrInvalidCursor
+	}
+	for i, s := range cursor.Sort {
+		if _, ok := sortColumns[s.Field]; !ok {
+			return nil, ErrInvalidCursor
+		}
+		if _, err := parseSortValue(s.Field, cursor.Values[i]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
+
+// Encode method for encoding the cursor into an opaque string.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// MatchesSort method for checking, if the cursor was created for the given sort order.
+func (c *Cursor) MatchesSort(sort []SortField) bool {
+	sort = normalizeSort(sort)
+	if len(sort) != len(c.Sort) {
+		return false
+	}
+	for i := range sort {
+		if sort[i] != c.Sort[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// afterClause builds the SQL condition selecting rows after the cursor.
+// Argument placeholders are numbered starting after argOffset.
+func (c *Cursor) afterClause(argOffset int) (string, []interface{}) {
+	args := make([]interface{}, 0, len(c.Values))
+	for i, s := range c.Sort {
+		v, _ := parseSortValue(s.Field, c.Values[i])
+		args = append(args, v)
+	}
+
+	placeholder := func(i int) string {
+		return "$" + strconv.Itoa(argOffset+i+1)
+	}
+
+	// Use a row comparison, if all keys have the same direction,
+	// so the keyset indexes can be used.
+	sameDirection := true
+	for _, s := range c.Sort {
+		sameDirection = sameDirection && s.Desc == c.Sort[0].Desc
+	}
+	if sameDirection {
+		columns := make([]string, 0, len(c.Sort))
+		values := make([]string, 0, len(c.Sort))
+		for i, s := range c.Sort {
+			columns = append(columns, sortColumns[s.Field])
+			values = append(values, placeholder(i))
+		}
+		op := ">"
+		if c.Sort[0].Desc {
+			op = "<"
+		}
+		return "(" + strings.Join(columns, ", ") + ") " + op + " (" + strings.Join(values, ", ") + ")", args
+	}
+
+	// Otherwise expand into (a > $1) OR (a = $1 AND b < $2) OR ...
+	alternatives := make([]string, 0, len(c.Sort))
+	for i, s := range c.Sort {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, sortColumns[c.Sort[j].Field]+" = "+placeholder(j))
+		}
+		op := " > "
+		if s.Desc {
+			op = " < "
+		}
+		terms = append(terms, sortColumns[s.Field]+op+placeholder(i))
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// normalizeSort returns the sort order with the ID tie-breaker appended,
+// exactly as it is used by orderClause.
+func normalizeSort(sort []SortField) []SortField {
+	if len(sort) == 0 {
+		sort = DefaultSort
+	}
+
+	normalized := make([]SortField, 0, len(sort)+1)
+	for _, s := range sort {
+		if s.Field == "id" {
+			return append(normalized, s)
+		}
+		normalized = append(normalized, s)
+	}
+
+	return append(normalized, SortField{Field: "id", Desc: sort[len(sort)-1].Desc})
+}
+
+// sortValue returns the string representation of a book's sort key.
+func sortValue(field string, b *Book) string {
+	switch field {
+	case "created_at":
+		return b.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return b.UpdatedAt.Format(time.RFC3339Nano)
+	case "title":
+		return b.Title
+	case "author":
+		return b.Author
+	case "book_status":
+		return strconv.Itoa(int(b.BookStatus))
+	case "rating":
+		return strconv.Itoa(b.BookAttrs.Rating)
+	default:
+		return b.ID.String()
+	}
+}
+
+// parseSortValue parses the string representation of a sort key
+// into the Go type used as a query argument.
+func parseSortValue(field, value string) (interface{}, error) {
+	switch field {
+	case "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, value)
+	case "title", "author":
+		return value, nil
+	case "book_status", "rating":
+		return strconv.Atoi(value)
+	case "id":
+		return uuid.Parse(value)
+	default:
+		return nil, fmt.Errorf("unknown sort field %q", field)
+	}
+}
@@ -0,0 +1,144 @@
+package books
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	book := &Book{
+		ID:         uuid.MustParse("7d3c1a52-8f0e-4b43-9a1c-2f4b5e6d7c8a"),
+		CreatedAt:  time.Date(2022, 9, 1, 12, 30, 0, 123456789, time.UTC),
+		UpdatedAt:  time.Date(2022, 9, 2, 8, 0, 0, 0, time.UTC),
+		Title:      "Go, \"quoted\"",
+		Author:     "Ann",
+		BookStatus: 1,
+		BookAttrs:  BookAttrs{Rating: 7},
+	}
+
+	tests := []struct {
+		name       string
+		sort       []SortField
+		wantSort   []SortField
+		wantValues []string
+	}{
+		{
+			name:       "default sort",
+			sort:       nil,
+			wantSort:   []SortField{{Field: "created_at", Desc: true}, {Field: "id", Desc: true}},
+			wantValues: []string{"2022-09-01T12:30:00.123456789Z", book.ID.String()},
+		},
+		{
+			name:       "mixed directions",
+			sort:       []SortField{{Field: "rating", Desc: true}, {Field: "title"}},
+			wantSort:   []SortField{{Field: "rating", Desc: true}, {Field: "title"}, {Field: "id"}},
+			wantValues: []string{"7", book.Title, book.ID.String()},
+		},
+		{
+			name:       "status",
+			sort:       []SortField{{Field: "book_status"}},
+			wantSort:   []SortField{{Field: "book_status"}, {Field: "id"}},
+			wantValues: []string{"1", book.ID.String()},
+		},
+		{
+			name:       "explicit id",
+			sort:       []SortField{{Field: "id", Desc: true}, {Field: "title"}},
+			wantSort:   []SortField{{Field: "id", Desc: true}},
+			wantValues: []string{book.ID.String()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := NewCursor(tt.sort, book)
+			if !reflect.DeepEqual(cursor.Sort, tt.wantSort) || !reflect.DeepEqual(cursor.Values, tt.wantValues) {
+				t.Fatalf("NewCursor() = %+v, want sort %v and values %v", cursor, tt.wantSort, tt.wantValues)
+			}
+
+			decoded, err := DecodeCursor(cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(decoded, cursor) {
+				t.Errorf("DecodeCursor() = %+v, want %+v", decoded, cursor)
+			}
+			if !decoded.MatchesSort(tt.sort) {
+				t.Errorf("MatchesSort(%v) = false, want true", tt.sort)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	id := uuid.NewString()
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not JSON", cursor: encode("cursor")},
+		{name: "empty sort", cursor: encode(`{"s":[],"v":[]}`)},
+		{name: "missing values", cursor: encode(`{"s":[{"f":"title"},{"f":"id"}],"v":["a"]}`)},
+		{name: "no id tie-breaker", cursor: encode(`{"s":[{"f":"title"}],"v":["a"]}`)},
+		{name: "unknown field", cursor: encode(`{"s":[{"f":"price"},{"f":"id"}],"v":["1","` + id + `"]}`)},
+		{name: "invalid time", cursor: encode(`{"s":[{"f":"created_at"},{"f":"id"}],"v":["yesterday","` + id + `"]}`)},
+		{name: "invalid number", cursor: encode(`{"s":[{"f":"rating"},{"f":"id"}],"v":["high","` + id + `"]}`)},
+		{name: "invalid id", cursor: encode(`{"s":[{"f":"id"}],"v":["42"]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want %v", tt.cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestCursorMatchesSort(t *testing.T) {
+	cursor := NewCursor([]SortField{{Field: "title"}}, &Book{ID: uuid.New()})
+
+	tests := []struct {
+		name string
+		sort []SortField
+		want bool
+	}{
+		{name: "same sort", sort: []SortField{{Field: "title"}}, want: true},
+		{name: "with tie-breaker", sort: []SortField{{Field: "title"}, {Field: "id"}}, want: true},
+		{name: "other direction", sort: []SortField{{Field: "title", Desc: true}}, want: false},
+		{name: "other field", sort: []SortField{{Field: "author"}}, want: false},
+		{name: "default sort", sort: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cursor.MatchesSort(tt.sort); got != tt.want {
+				t.Errorf("MatchesSort(%v) = %v, want %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorAfterClauseUnrated(t *testing.T) {
+	id := uuid.New()
+	cursor := NewCursor([]SortField{{Field: "rating", Desc: true}}, &Book{ID: id})
+
+	where, args := cursor.afterClause(1)
+
+	want := "(COALESCE((book_attrs->>'rating')::int, 0), id) < ($2, $3)"
+	if where != want {
+		t.Errorf("afterClause() = %q, want %q", where, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{0, id}) {
+		t.Errorf("afterClause() args = %v, want %v", args, []interface{}{0, id})
+	}
+}
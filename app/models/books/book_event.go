@@ -0,0 +1,70 @@
+package books
+
+import (
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+	"github.com/google/uuid"
+)
+
+// AggregateType is the aggregate type of outbox messages of books.
+const AggregateType = "book"
+
+// Event types of changed books.
+const (
+	EventCreated       = "book.created"
+	EventUpdated       = "book.updated"
+	EventStatusChanged = "book.status_changed"
+	EventDeleted       = "book.deleted"
+	EventRestored      = "book.restored"
+	EventPurged        = "book.purged"
+)
+
+// EventTypes are all event types of changed books.
+var EventTypes = []string{EventCreated, EventUpdated, EventStatusChanged, EventDeleted, EventRestored, EventPurged}
+
+// Event struct to describe a change of a book, which is committed.
+// Book is the state after the change, it only holds the ID for purged books.
+type Event struct {
+	ID         uuid.UUID  `json:"id"`
+	Type       string     `json:"type"`
+	OccurredAt time.Time  `json:"occurred_at"`
+	ActorID    *uuid.UUID `json:"actor_id"`
+	Book       Book       `json:"book"`
+}
+
+// newEvent func for creating an event of the given type.
+func newEvent(eventType string, book Book, actor uuid.UUID) Event {
+	e := Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Book:       book,
+	}
+	if actor != uuid.Nil {
+		e.ActorID = &actor
+	}
+	return e
+}
+
+// message method for creating the outbox message publishing the event.
+func (e Event) message() (*outbox.Message, error) {
+	return outbox.NewMessage(e.ID, e.Type, AggregateType, e.Book.ID, e)
+}
+
+// revisionEvents maps revision actions to event types,
+// other actions are status transitions.
+var revisionEvents = map[string]string{
+	RevisionCreate:  EventCreated,
+	RevisionUpdate:  EventUpdated,
+	RevisionDelete:  EventDeleted,
+	RevisionRestore: EventRestored,
+}
+
+// eventType returns the type of events of a revision action.
+func eventType(action string) string {
+	if t, ok := revisionEvents[action]; ok {
+		return t
+	}
+	return EventStatusChanged
+}
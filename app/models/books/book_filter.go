@@ -0,0 +1,159 @@
+package books
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookListParams struct to describe options for listing books.
+type BookListParams struct {
+	Limit  int
+	Offset int
+	Filter BookFilter
+	Sort   []SortField
+}
+
+// BookFilter struct to describe filters for listing books.
+// Nil pointers and empty strings mean "no filter".
+// Only books in the trash are listed, if Deleted is set, otherwise only live ones.
+type BookFilter struct {
+	Deleted     bool
+	UserID      *uuid.UUID
+	Author      string
+	Title       string // substring, case-insensitive
+	BookStatus  *Status
+	RatingMin   *int
+	RatingMax   *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+}
+
+// SortField struct to describe one sort key of the books listing.
+type SortField struct {
+	Field string `json:"f"`
+	Desc  bool   `json:"d,omitempty"`
+}
+
+// sortColumns maps sortable API field names to SQL expressions.
+// Expressions must not be NULL, a book without a rating sorts as rated 0.
+var sortColumns = map[string]string{
+	"id":          "id",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+	"title":       "title",
+	"author":      "author",
+	"book_status": "book_status",
+	"rating":      "COALESCE((book_attrs->>'rating')::int, 0)",
+}
+
+// DefaultSort is used when no sort order is given.
+var DefaultSort = []SortField{{Field: "created_at", Desc: true}}
+
+// ParseSort func for parsing a sort expression like "-created_at,title".
+// A leading "-" means descending order, an optional "+" means ascending.
+func ParseSort(s string) ([]SortField, error) {
+	fields := []SortField{}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: part}
+		switch part[0] {
+		case '-':
+			field = SortField{Field: part[1:], Desc: true}
+		case '+':
+			field = SortField{Field: part[1:]}
+		}
+
+		if _, ok := sortColumns[field.Field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", field.Field)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field.Field)
+		}
+		seen[field.Field] = true
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// whereClause builds the SQL WHERE clause and its arguments for the filter.
+func (f BookFilter) whereClause() (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if f.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if f.UserID != nil {
+		add("user_id = ?", *f.UserID)
+	}
+	if f.Author != "" {
+		add("lower(author) = lower(?)", f.Author)
+	}
+	if f.Title != "" {
+		add(`title ILIKE ? ESCAPE '\'`, "%"+escapeLike(f.Title)+"%")
+	}
+	if f.BookStatus != nil {
+		add("book_status = ?", *f.BookStatus)
+	}
+	if f.RatingMin != nil {
+		add("(book_attrs->>'rating')::int >= ?", *f.RatingMin)
+	}
+	if f.RatingMax != nil {
+		add("(book_attrs->>'rating')::int <= ?", *f.RatingMax)
+	}
+	if f.CreatedFrom != nil {
+		add("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add("created_at <= ?", *f.CreatedTo)
+	}
+	if f.UpdatedFrom != nil {
+		add("updated_at >= ?", *f.UpdatedFrom)
+	}
+	if f.UpdatedTo != nil {
+		add("updated_at <= ?", *f.UpdatedTo)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderClause builds the SQL ORDER BY clause for the given sort fields.
+// The primary key is always appended as a tie-breaker to keep pages stable.
+func orderClause(sort []SortField) string {
+	parts := []string{}
+	for _, s := range normalizeSort(sort) {
+		part := sortColumns[s.Field]
+		if s.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// escapeLike escapes LIKE wildcards in a user supplied string.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
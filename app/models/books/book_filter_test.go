@@ -0,0 +1,42 @@
+package books
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []SortField
+		wantErr bool
+	}{
+		{name: "empty", sort: "", want: []SortField{}},
+		{name: "ascending", sort: "title", want: []SortField{{Field: "title"}}},
+		{name: "explicit ascending", sort: "+title", want: []SortField{{Field: "title"}}},
+		{name: "descending", sort: "-created_at", want: []SortField{{Field: "created_at", Desc: true}}},
+		{
+			name: "several fields",
+			sort: "-rating, author ,id",
+			want: []SortField{{Field: "rating", Desc: true}, {Field: "author"}, {Field: "id"}},
+		},
+		{name: "empty parts", sort: ",title,,", want: []SortField{{Field: "title"}}},
+		{name: "unknown field", sort: "price", wantErr: true},
+		{name: "column expression", sort: "book_attrs", wantErr: true},
+		{name: "duplicate field", sort: "title,-title", wantErr: true},
+		{name: "sign only", sort: "-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.sort)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort(%q) error = %v, wantErr %v", tt.sort, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %v, want %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
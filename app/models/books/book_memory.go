@@ -0,0 +1,420 @@
+package books
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/outbox"
+	"github.com/google/uuid"
+)
+
+// errDuplicateID mirrors the primary key of books.
+var errDuplicateID = &models.Error{
+	Kind: models.ErrConflict,
+	Err:  errors.New("book with this ID already exists"),
+}
+
+// BookMemory struct for keeping books in memory.
+// It is safe for concurrent use and mirrors the behaviour of BookQueries.
+type BookMemory struct {
+	mu        sync.RWMutex
+	books     map[uuid.UUID]Book
+	revisions map[uuid.UUID][]Revision // oldest first
+	outbox    *outbox.OutboxMemory
+}
+
+// NewBookMemory func for creating an empty in-memory book storage,
+// which publishes changes to the given outbox.
+func NewBookMemory(outbox *outbox.OutboxMemory) *BookMemory {
+	return &BookMemory{
+		books:     map[uuid.UUID]Book{},
+		revisions: map[uuid.UUID][]Revision{},
+		outbox:    outbox,
+	}
+}
+
+// GetBooks method for getting a page of books by given list params.
+func (m *BookMemory) GetBooks(params BookListParams) ([]Book, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	books := m.filter(params.Filter, params.Sort, nil)
+	total := len(books)
+
+	// Cut requested page.
+	if params.Offset >= len(books) {
+		return []Book{}, total, nil
+	}
+	books = books[params.Offset:]
+	if len(books) > params.Limit {
+		books = books[:params.Limit]
+	}
+
+	return books, total, nil
+}
+
+// GetBooksAfter method for getting a page of books by keyset pagination.
+func (m *BookMemory) GetBooksAfter(params BookListParams, cursor *Cursor) ([]Book, *Cursor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	books := m.filter(params.Filter, params.Sort, cursor)
+
+	// Return the page with the next cursor, if there are more books.
+	if len(books) > params.Limit {
+		books = books[:params.Limit]
+		return books, NewCursor(params.Sort, &books[len(books)-1]), nil
+	}
+
+	return books, nil, nil
+}
+
+// GetBook method for getting one book by given ID.
+func (m *BookMemory) GetBook(id uuid.UUID) (Book, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt != nil {
+		return Book{}, models.ErrNotFound
+	}
+
+	return book, nil
+}
+
+// GetDeletedBook method for getting one book in the trash by given ID.
+func (m *BookMemory) GetDeletedBook(id uuid.UUID) (Book, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt == nil {
+		return Book{}, models.ErrNotFound
+	}
+
+	return book, nil
+}
+
+// CreateBook method for creating book by given Book object.
+func (m *BookMemory) CreateBook(b *Book, actor uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.books[b.ID]; ok {
+		return errDuplicateID
+	}
+
+	m.books[b.ID] = *b
+	m.addRevision(*b, RevisionCreate, actor)
+
+	return nil
+}
+
+// UpdateBook method for updating book by given Book object.
+// The book is only updated, if its stored version is still b.Version.
+func (m *BookMemory) UpdateBook(id uuid.UUID, b *Book, actor uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt != nil || book.Version != b.Version {
+		return ErrVersionConflict
+	}
+
+	b.Version++
+	book.Version = b.Version
+	book.UpdatedAt = b.UpdatedAt
+	book.Title = b.Title
+	book.Author = b.Author
+	book.BookStatus = b.BookStatus
+	book.StatusChangedAt = b.StatusChangedAt
+	book.BookAttrs = b.BookAttrs
+	m.books[id] = book
+	m.addRevision(book, RevisionUpdate, actor)
+
+	return nil
+}
+
+// TransitionBook method for changing the status of book by given Book object.
+// The book is only changed, if its stored version is still b.Version.
+func (m *BookMemory) TransitionBook(id uuid.UUID, b *Book, transition string, actor uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt != nil || book.Version != b.Version {
+		return ErrVersionConflict
+	}
+
+	b.Version++
+	book.Version = b.Version
+	book.UpdatedAt = b.UpdatedAt
+	book.BookStatus = b.BookStatus
+	book.StatusChangedAt = b.StatusChangedAt
+	m.books[id] = book
+	m.addRevision(book, transition, actor)
+
+	return nil
+}
+
+// DeleteBook method for moving book by given ID and version to the trash.
+func (m *BookMemory) DeleteBook(id uuid.UUID, version int, actor uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt != nil || book.Version != version {
+		return ErrVersionConflict
+	}
+
+	now := time.Now()
+	book.DeletedAt = &now
+	book.Version++
+	m.books[id] = book
+	m.addRevision(book, RevisionDelete, actor)
+
+	return nil
+}
+
+// RestoreBook method for restoring book by given ID from the trash.
+func (m *BookMemory) RestoreBook(id uuid.UUID, actor uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt == nil {
+		return models.ErrNotFound
+	}
+
+	book.DeletedAt = nil
+	book.Version++
+	m.books[id] = book
+	m.addRevision(book, RevisionRestore, actor)
+
+	return nil
+}
+
+// PurgeBook method for permanently deleting book by given ID from the trash.
+func (m *BookMemory) PurgeBook(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[id]
+	if !ok || book.DeletedAt == nil {
+		return models.ErrNotFound
+	}
+
+	delete(m.books, id)
+	delete(m.revisions, id)
+	m.enqueue(newEvent(EventPurged, Book{ID: id}, uuid.Nil))
+
+	return nil
+}
+
+// PurgeDeletedBooks method for permanently deleting books moved to the trash
+// before the given time. It returns the IDs of purged books.
+func (m *BookMemory) PurgeDeletedBooks(before time.Time) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := []uuid.UUID{}
+	for id, book := range m.books {
+		if book.DeletedAt != nil && book.DeletedAt.Before(before) {
+			delete(m.books, id)
+			delete(m.revisions, id)
+			m.enqueue(newEvent(EventPurged, Book{ID: id}, uuid.Nil))
+			purged = append(purged, id)
+		}
+	}
+
+	return purged, nil
+}
+
+// GetBookRevisions method for getting a page of revisions of a book,
+// the latest first. It also returns the total number of revisions.
+func (m *BookMemory) GetBookRevisions(bookID uuid.UUID, limit, offset int) ([]Revision, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.revisions[bookID]
+	total := len(stored)
+
+	// Cut requested page, counting from the latest revision.
+	revisions := []Revision{}
+	for i := total - 1 - offset; i >= 0 && len(revisions) < limit; i-- {
+		revisions = append(revisions, stored[i])
+	}
+
+	return revisions, total, nil
+}
+
+// GetBookRevision method for getting one revision of a book.
+func (m *BookMemory) GetBookRevision(bookID uuid.UUID, revision int) (Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.revisions[bookID] {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+
+	return Revision{}, models.ErrNotFound
+}
+
+// addRevision records the changed book and publishes the change.
+// The caller must hold the lock.
+func (m *BookMemory) addRevision(book Book, action string, actor uuid.UUID) {
+	r := Revision{
+		BookID:    book.ID,
+		Revision:  book.Version,
+		CreatedAt: time.Now(),
+		Action:    action,
+		Book:      Snapshot(book),
+	}
+	if actor != uuid.Nil {
+		r.UserID = &actor
+	}
+
+	m.revisions[book.ID] = append(m.revisions[book.ID], r)
+	m.enqueue(newEvent(eventType(action), book, actor))
+}
+
+// enqueue adds the outbox message of the event while holding the lock
+// of the change, so messages of a book are in order of its changes.
+func (m *BookMemory) enqueue(e Event) {
+	// Events of books always encode, their fields are plain values.
+	if msg, err := e.message(); err == nil {
+		m.outbox.Enqueue(msg)
+	}
+}
+
+// filter returns the books matching the filter and following the cursor,
+// ordered by the given sort fields. The caller must hold the lock.
+func (m *BookMemory) filter(f BookFilter, sortFields []SortField, cursor *Cursor) []Book {
+	sortFields = normalizeSort(sortFields)
+
+	var after []interface{}
+	if cursor != nil {
+		for i, s := range cursor.Sort {
+			v, _ := parseSortValue(s.Field, cursor.Values[i])
+			after = append(after, v)
+		}
+	}
+
+	books := []Book{}
+	for _, b := range m.books {
+		if !f.matches(&b) {
+			continue
+		}
+		if after != nil && compareToValues(&b, cursor.Sort, after) <= 0 {
+			continue
+		}
+		books = append(books, b)
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		return compareToValues(&books[i], sortFields, sortValues(&books[j], sortFields)) < 0
+	})
+
+	return books
+}
+
+// matches reports whether the book satisfies the filter.
+func (f BookFilter) matches(b *Book) bool {
+	switch {
+	case f.Deleted != (b.DeletedAt != nil):
+		return false
+	case f.UserID != nil && b.UserID != *f.UserID:
+		return false
+	case f.Author != "" && !strings.EqualFold(b.Author, f.Author):
+		return false
+	case f.Title != "" && !strings.Contains(strings.ToLower(b.Title), strings.ToLower(f.Title)):
+		return false
+	case f.BookStatus != nil && b.BookStatus != *f.BookStatus:
+		return false
+	case f.RatingMin != nil && b.BookAttrs.Rating < *f.RatingMin:
+		return false
+	case f.RatingMax != nil && b.BookAttrs.Rating > *f.RatingMax:
+		return false
+	case f.CreatedFrom != nil && b.CreatedAt.Before(*f.CreatedFrom):
+		return false
+	case f.CreatedTo != nil && b.CreatedAt.After(*f.CreatedTo):
+		return false
+	case f.UpdatedFrom != nil && b.UpdatedAt.Before(*f.UpdatedFrom):
+		return false
+	case f.UpdatedTo != nil && b.UpdatedAt.After(*f.UpdatedTo):
+		return false
+	}
+	return true
+}
+
+// sortValues returns the typed sort key values of a book.
+func sortValues(b *Book, sortFields []SortField) []interface{} {
+	values := make([]interface{}, 0, len(sortFields))
+	for _, s := range sortFields {
+		values = append(values, fieldValue(s.Field, b))
+	}
+	return values
+}
+
+// compareToValues compares a book with sort key values in the listing order.
+// It returns a negative number, if the book comes first.
+func compareToValues(b *Book, sortFields []SortField, values []interface{}) int {
+	for i, s := range sortFields {
+		c := compareValues(fieldValue(s.Field, b), values[i])
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// fieldValue returns the typed value of a book's sort key.
+func fieldValue(field string, b *Book) interface{} {
+	switch field {
+	case "created_at":
+		return b.CreatedAt
+	case "updated_at":
+		return b.UpdatedAt
+	case "title":
+		return b.Title
+	case "author":
+		return b.Author
+	case "book_status":
+		return int(b.BookStatus)
+	case "rating":
+		return b.BookAttrs.Rating
+	default:
+		return b.ID
+	}
+}
+
+// compareValues compares two sort key values of the same type.
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		t := b.(time.Time)
+		switch {
+		case a.Before(t):
+			return -1
+		case a.After(t):
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case int:
+		return a - b.(int)
+	case uuid.UUID:
+		return strings.Compare(a.String(), b.(uuid.UUID).String())
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package books
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/outbox"
+	"github.com/google/uuid"
+)
+
+func newMemoryBook() *Book {
+	now := time.Now()
+	return &Book{
+		ID:              uuid.New(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Version:         1,
+		UserID:          uuid.New(),
+		Title:           "Title",
+		Author:          "Author",
+		BookStatus:      StatusDraft,
+		StatusChangedAt: &now,
+		BookAttrs:       BookAttrs{Rating: 5},
+	}
+}
+
+func TestBookMemoryCreateDuplicateID(t *testing.T) {
+	m := NewBookMemory(outbox.NewOutboxMemory())
+	book := newMemoryBook()
+	if err := m.CreateBook(book, book.UserID); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	duplicate := *book
+	duplicate.Title = "Other title"
+	if err := m.CreateBook(&duplicate, book.UserID); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("CreateBook() error = %v, want %v", err, models.ErrConflict)
+	}
+
+	stored, err := m.GetBook(book.ID)
+	if err != nil {
+		t.Fatalf("GetBook() error = %v", err)
+	}
+	if stored.Title != book.Title {
+		t.Errorf("GetBook() title = %q, want %q", stored.Title, book.Title)
+	}
+	if _, total, _ := m.GetBookRevisions(book.ID, 10, 0); total != 1 {
+		t.Errorf("GetBookRevisions() total = %d, want 1", total)
+	}
+}
+
+func TestBookMemoryVersionConflict(t *testing.T) {
+	m := NewBookMemory(outbox.NewOutboxMemory())
+	book := newMemoryBook()
+	if err := m.CreateBook(book, book.UserID); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	// Like BookQueries, a successful update increments the version of b.
+	update := *book
+	update.Title = "New title"
+	if err := m.UpdateBook(book.ID, &update, book.UserID); err != nil {
+		t.Fatalf("UpdateBook() error = %v", err)
+	}
+	if update.Version != 2 {
+		t.Errorf("UpdateBook() version = %d, want 2", update.Version)
+	}
+
+	tests := []struct {
+		name   string
+		change func() error
+		want   error
+	}{
+		{
+			name:   "update stale version",
+			change: func() error { stale := *book; return m.UpdateBook(book.ID, &stale, book.UserID) },
+			want:   ErrVersionConflict,
+		},
+		{
+			name: "transition stale version",
+			change: func() error {
+				stale := *book
+				return m.TransitionBook(book.ID, &stale, "publish", book.UserID)
+			},
+			want: ErrVersionConflict,
+		},
+		{
+			name:   "delete stale version",
+			change: func() error { return m.DeleteBook(book.ID, book.Version, book.UserID) },
+			want:   ErrVersionConflict,
+		},
+		{
+			name:   "update unknown book",
+			change: func() error { other := newMemoryBook(); return m.UpdateBook(other.ID, other, other.UserID) },
+			want:   ErrVersionConflict,
+		},
+		{
+			name:   "restore book not in trash",
+			change: func() error { return m.RestoreBook(book.ID, book.UserID) },
+			want:   models.ErrNotFound,
+		},
+		{
+			name:   "purge book not in trash",
+			change: func() error { return m.PurgeBook(book.ID) },
+			want:   models.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	stored, err := m.GetBook(book.ID)
+	if err != nil {
+		t.Fatalf("GetBook() error = %v", err)
+	}
+	if stored.Version != 2 || stored.Title != "New title" {
+		t.Errorf("GetBook() = version %d title %q, want version 2 title %q", stored.Version, stored.Title, "New title")
+	}
+}
+
+func TestBookMemoryDeleted(t *testing.T) {
+	m := NewBookMemory(outbox.NewOutboxMemory())
+	book := newMemoryBook()
+	if err := m.CreateBook(book, book.UserID); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	if err := m.DeleteBook(book.ID, book.Version, book.UserID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+
+	if _, err := m.GetBook(book.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetBook() error = %v, want %v", err, models.ErrNotFound)
+	}
+	deleted, err := m.GetDeletedBook(book.ID)
+	if err != nil {
+		t.Fatalf("GetDeletedBook() error = %v", err)
+	}
+	update := deleted
+	if err := m.UpdateBook(book.ID, &update, book.UserID); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("UpdateBook() error = %v, want %v", err, ErrVersionConflict)
+	}
+
+	if err := m.RestoreBook(book.ID, book.UserID); err != nil {
+		t.Fatalf("RestoreBook() error = %v", err)
+	}
+	restored, err := m.GetBook(book.ID)
+	if err != nil {
+		t.Fatalf("GetBook() error = %v", err)
+	}
+	if restored.Version != deleted.Version+1 {
+		t.Errorf("GetBook() version = %d, want %d", restored.Version, deleted.Version+1)
+	}
+}
+
+func TestBookMemoryConcurrentUpdates(t *testing.T) {
+	m := NewBookMemory(outbox.NewOutboxMemory())
+	book := newMemoryBook()
+	if err := m.CreateBook(book, book.UserID); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	// Every writer retries on conflicts, like a client re-reading the book.
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				current, err := m.GetBook(book.ID)
+				if err != nil {
+					t.Errorf("GetBook() error = %v", err)
+					return
+				}
+				current.BookAttrs.Rating++
+				err = m.UpdateBook(book.ID, &current, book.UserID)
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, ErrVersionConflict) {
+					t.Errorf("UpdateBook() error = %v", err)
+					return
+				}
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := m.GetBooks(BookListParams{Limit: 10, Sort: DefaultSort}); err != nil {
+				t.Errorf("GetBooks() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := m.GetBook(book.ID)
+	if err != nil {
+		t.Fatalf("GetBook() error = %v", err)
+	}
+	if stored.Version != 1+writers {
+		t.Errorf("GetBook() version = %d, want %d", stored.Version, 1+writers)
+	}
+	if stored.BookAttrs.Rating != 5+writers {
+		t.Errorf("GetBook() rating = %d, want %d, updates were lost", stored.BookAttrs.Rating, 5+writers)
+	}
+	if _, total, _ := m.GetBookRevisions(book.ID, 1, 0); total != 1+writers {
+		t.Errorf("GetBookRevisions() total = %d, want %d", total, 1+writers)
+	}
+}
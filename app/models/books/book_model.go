@@ -12,14 +12,17 @@ import (
 
 // Book struct to describe book object.
 type Book struct {
-	ID         uuid.UUID `db:"id" json:"id" validate:"required,uuid"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
-	UserID     uuid.UUID `db:"user_id" json:"user_id" validate:"required,uuid"`
-	Title      string    `db:"title" json:"title" validate:"required,lte=255"`
-	Author     string    `db:"author" json:"author" validate:"required,lte=255"`
-	BookStatus int       `db:"book_status" json:"book_status" validate:"required,len=1"`
-	BookAttrs  BookAttrs `db:"book_attrs" json:"book_attrs" validate:"required,dive"`
+	ID              uuid.UUID  `db:"id" json:"id" validate:"required,uuid"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Version         int        `db:"version" json:"version"`
+	UserID          uuid.UUID  `db:"user_id" json:"user_id" validate:"required,uuid"`
+	Title           string     `db:"title" json:"title" validate:"required,lte=255"`
+	Author          string     `db:"author" json:"author" validate:"required,lte=255"`
+	BookStatus      Status     `db:"book_status" json:"book_status" swaggertype:"string" enums:"draft,active,archived,withdrawn"`
+	StatusChangedAt *time.Time `db:"status_changed_at" json:"status_changed_at,omitempty"`
+	BookAttrs       BookAttrs  `db:"book_attrs" json:"book_attrs" validate:"required,dive"`
 }
 
 // BookAttrs struct to describe book attributes.
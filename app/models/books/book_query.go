@@ -1,6 +1,13 @@
 package books
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/models/outbox"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -10,88 +17,349 @@ type BookQueries struct {
 	*sqlx.DB
 }
 
-// GetBooks method for getting all books.
-func (q *BookQueries) GetBooks() ([]Book, error) {
-	// Define books variable.
+// bookColumns lists the columns of the books table scanned into Book.
+const bookColumns = `id, created_at, updated_at, deleted_at, version, user_id, title, author, book_status, status_changed_at, book_attrs`
+
+// GetBooks method for getting a page of books by given list params.
+// It also returns the total number of books matching the filter.
+func (q *BookQueries) GetBooks(params BookListParams) ([]Book, int, error) {
+	// Define books and total variables.
 	books := []Book{}
+	total := 0
+
+	// Build filter clause.
+	where, args := params.Filter.whereClause()
+
+	// Count books matching the filter.
+	if err := q.Get(&total, `SELECT count(*) FROM books`+where, args...); err != nil {
+		// Return empty object and error.
+		return books, 0, models.DBError(err)
+	}
 
 	// Define query string.
-	query := `SELECT * FROM books`
+	query := `SELECT ` + bookColumns + ` FROM books` + where + orderClause(params.Sort) +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
 
 	// Send query to database.
-	err := q.Select(&books, query)
+	err := q.Select(&books, query, append(args, params.Limit, params.Offset)...)
 	if err != nil {
 		// Return empty object and error.
-		return books, err
+		return books, 0, models.DBError(err)
 	}
 
 	// Return query result.
-	return books, nil
+	return books, total, nil
+}
+
+// GetBooksAfter method for getting a page of books by keyset pagination.
+// It returns books following the given cursor (or the first page for a nil
+// cursor) and a cursor for the next page, which is nil on the last page.
+func (q *BookQueries) GetBooksAfter(params BookListParams, cursor *Cursor) ([]Book, *Cursor, error) {
+	// Define books variable.
+	books := []Book{}
+
+	// Build filter clause.
+	where, args := params.Filter.whereClause()
+
+	// Restrict to rows after the cursor.
+	if cursor != nil {
+		after, afterArgs := cursor.afterClause(len(args))
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+
+	// Define query string, fetching one extra row to detect the next page.
+	query := `SELECT ` + bookColumns + ` FROM books` + where + orderClause(params.Sort) +
+		fmt.Sprintf(" LIMIT $%d", len(args)+1)
+
+	// Send query to database.
+	err := q.Select(&books, query, append(args, params.Limit+1)...)
+	if err != nil {
+		// Return empty object and error.
+		return books, nil, models.DBError(err)
+	}
+
+	// Return query result with the next cursor, if there are more books.
+	if len(books) > params.Limit {
+		books = books[:params.Limit]
+		return books, NewCursor(params.Sort, &books[len(books)-1]), nil
+	}
+
+	return books, nil, nil
 }
 
 // GetBook method for getting one book by given ID.
+// Books in the trash are not found.
 func (q *BookQueries) GetBook(id uuid.UUID) (Book, error) {
 	// Define book variable.
 	book := Book{}
 
 	// Define query string.
-	query := `SELECT * FROM books WHERE id = $1`
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = $1 AND deleted_at IS NULL`
 
 	// Send query to database.
 	err := q.Get(&book, query, id)
 	if err != nil {
 		// Return empty object and error.
-		return book, err
+		return book, models.DBError(err)
 	}
 
 	// Return query result.
 	return book, nil
 }
 
-// CreateBook method for creating book by given Book object.
-func (q *BookQueries) CreateBook(b *Book) error {
+// GetDeletedBook method for getting one book in the trash by given ID.
+func (q *BookQueries) GetDeletedBook(id uuid.UUID) (Book, error) {
+	// Define book variable.
+	book := Book{}
+
 	// Define query string.
-	query := `INSERT INTO books VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = $1 AND deleted_at IS NOT NULL`
 
 	// Send query to database.
-	_, err := q.Exec(query, b.ID, b.CreatedAt, b.UpdatedAt, b.UserID, b.Title, b.Author, b.BookStatus, b.BookAttrs)
+	err := q.Get(&book, query, id)
 	if err != nil {
-		// Return only error.
-		return err
+		// Return empty object and error.
+		return book, models.DBError(err)
 	}
 
-	// This query returns nothing.
-	return nil
+	// Return query result.
+	return book, nil
+}
+
+// CreateBook method for creating book by given Book object.
+// The created book is recorded as the first revision made by the actor.
+func (q *BookQueries) CreateBook(b *Book, actor uuid.UUID) error {
+	return q.withRevision(RevisionCreate, actor, func(tx *sqlx.Tx, book *Book) error {
+		// Define query string.
+		query := `INSERT INTO books (id, created_at, updated_at, version, user_id, title, author, book_status, status_changed_at, book_attrs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING ` + bookColumns
+
+		// Send query to database.
+		return tx.Get(book, query, b.ID, b.CreatedAt, b.UpdatedAt, b.Version, b.UserID, b.Title, b.Author, b.BookStatus, b.StatusChangedAt, b.BookAttrs)
+	})
 }
 
 // UpdateBook method for updating book by given Book object.
-func (q *BookQueries) UpdateBook(id uuid.UUID, b *Book) error {
+// The book is only updated, if its stored version is still b.Version,
+// on success b.Version is set to the incremented version.
+func (q *BookQueries) UpdateBook(id uuid.UUID, b *Book, actor uuid.UUID) error {
+	return q.withRevision(RevisionUpdate, actor, func(tx *sqlx.Tx, book *Book) error {
+		// Define query string.
+		query := `UPDATE books SET updated_at = $2, title = $3, author = $4, book_status = $5, status_changed_at = $6, book_attrs = $7, version = version + 1 WHERE id = $1 AND version = $8 AND deleted_at IS NULL RETURNING ` + bookColumns
+
+		// Send query to database.
+		err := tx.Get(book, query, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.StatusChangedAt, b.BookAttrs, b.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return conflict, the book was changed or deleted meanwhile.
+			return ErrVersionConflict
+		}
+
+		b.Version = book.Version
+		return err
+	})
+}
+
+// TransitionBook method for changing the status of book by given Book object.
+// The change is recorded as a revision with the name of the transition as action.
+// The book is only changed, if its stored version is still b.Version,
+// on success b.Version is set to the incremented version.
+func (q *BookQueries) TransitionBook(id uuid.UUID, b *Book, transition string, actor uuid.UUID) error {
+	return q.withRevision(transition, actor, func(tx *sqlx.Tx, book *Book) error {
+		// Define query string.
+		query := `UPDATE books SET updated_at = $2, book_status = $3, status_changed_at = $4, version = version + 1 WHERE id = $1 AND version = $5 AND deleted_at IS NULL RETURNING ` + bookColumns
+
+		// Send query to database.
+		err := tx.Get(book, query, id, b.UpdatedAt, b.BookStatus, b.StatusChangedAt, b.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return conflict, the book was changed or deleted meanwhile.
+			return ErrVersionConflict
+		}
+
+		b.Version = book.Version
+		return err
+	})
+}
+
+// DeleteBook method for moving book by given ID and version to the trash.
+func (q *BookQueries) DeleteBook(id uuid.UUID, version int, actor uuid.UUID) error {
+	return q.withRevision(RevisionDelete, actor, func(tx *sqlx.Tx, book *Book) error {
+		// Define query string.
+		query := `UPDATE books SET deleted_at = now(), version = version + 1 WHERE id = $1 AND version = $2 AND deleted_at IS NULL RETURNING ` + bookColumns
+
+		// Send query to database.
+		err := tx.Get(book, query, id, version)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Return conflict, the book was changed or deleted meanwhile.
+			return ErrVersionConflict
+		}
+
+		return err
+	})
+}
+
+// RestoreBook method for restoring book by given ID from the trash.
+func (q *BookQueries) RestoreBook(id uuid.UUID, actor uuid.UUID) error {
+	return q.withRevision(RevisionRestore, actor, func(tx *sqlx.Tx, book *Book) error {
+		// Define query string.
+		query := `UPDATE books SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL RETURNING ` + bookColumns
+
+		// Send query to database, no rows mean the book is not in the trash.
+		return tx.Get(book, query, id)
+	})
+}
+
+// PurgeBook method for permanently deleting book by given ID from the trash.
+func (q *BookQueries) PurgeBook(id uuid.UUID) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return models.DBError(err)
+	}
+	defer tx.Rollback()
+
 	// Define query string.
-	query := `UPDATE books SET updated_at = $2, title = $3, author = $4, book_status = $5, book_attrs = $6 WHERE id = $1`
+	query := `DELETE FROM books WHERE id = $1 AND deleted_at IS NOT NULL`
 
 	// Send query to database.
-	_, err := q.Exec(query, id, b.UpdatedAt, b.Title, b.Author, b.BookStatus, b.BookAttrs)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		// Return only error.
+		return models.DBError(err)
+	}
+
+	// Checking, if the book is in the trash.
+	if err := models.ExpectRows(result); err != nil {
+		return err
+	}
+
+	// Publish the purge.
+	if err := enqueue(tx, newEvent(EventPurged, Book{ID: id}, uuid.Nil)); err != nil {
 		return err
 	}
 
-	// This query returns nothing.
-	return nil
+	return models.DBError(tx.Commit())
 }
 
-// DeleteBook method for delete book by given ID.
-func (q *BookQueries) DeleteBook(id uuid.UUID) error {
+// PurgeDeletedBooks method for permanently deleting books moved to the trash
+// before the given time. It returns the IDs of purged books.
+func (q *BookQueries) PurgeDeletedBooks(before time.Time) ([]uuid.UUID, error) {
+	tx, err := q.Beginx()
+	if err != nil {
+		return nil, models.DBError(err)
+	}
+	defer tx.Rollback()
+
+	// Define IDs variable.
+	ids := []uuid.UUID{}
+
 	// Define query string.
-	query := `DELETE FROM books WHERE id = $1`
+	query := `DELETE FROM books WHERE deleted_at < $1 RETURNING id`
 
 	// Send query to database.
-	_, err := q.Exec(query, id)
-	if err != nil {
+	if err := tx.Select(&ids, query, before); err != nil {
 		// Return only error.
+		return nil, models.DBError(err)
+	}
+
+	// Publish the purges.
+	for _, id := range ids {
+		if err := enqueue(tx, newEvent(EventPurged, Book{ID: id}, uuid.Nil)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Return IDs of purged books.
+	if err := tx.Commit(); err != nil {
+		return nil, models.DBError(err)
+	}
+	return ids, nil
+}
+
+// GetBookRevisions method for getting a page of revisions of a book,
+// the latest first. It also returns the total number of revisions.
+func (q *BookQueries) GetBookRevisions(bookID uuid.UUID, limit, offset int) ([]Revision, int, error) {
+	// Define revisions and total variables.
+	revisions := []Revision{}
+	total := 0
+
+	// Count revisions of the book.
+	if err := q.Get(&total, `SELECT count(*) FROM book_revisions WHERE book_id = $1`, bookID); err != nil {
+		// Return empty object and error.
+		return revisions, 0, models.DBError(err)
+	}
+
+	// Define query string.
+	query := `SELECT * FROM book_revisions WHERE book_id = $1 ORDER BY revision DESC LIMIT $2 OFFSET $3`
+
+	// Send query to database.
+	err := q.Select(&revisions, query, bookID, limit, offset)
+	if err != nil {
+		// Return empty object and error.
+		return revisions, 0, models.DBError(err)
+	}
+
+	// Return query result.
+	return revisions, total, nil
+}
+
+// GetBookRevision method for getting one revision of a book.
+func (q *BookQueries) GetBookRevision(bookID uuid.UUID, revision int) (Revision, error) {
+	// Define revision variable.
+	r := Revision{}
+
+	// Define query string.
+	query := `SELECT * FROM book_revisions WHERE book_id = $1 AND revision = $2`
+
+	// Send query to database.
+	err := q.Get(&r, query, bookID, revision)
+	if err != nil {
+		// Return empty object and error.
+		return r, models.DBError(err)
+	}
+
+	// Return query result.
+	return r, nil
+}
+
+// withRevision runs the change of a book, records the changed book
+// as a new revision and publishes the change in one transaction.
+func (q *BookQueries) withRevision(action string, actor uuid.UUID, change func(tx *sqlx.Tx, book *Book) error) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return models.DBError(err)
+	}
+	defer tx.Rollback()
+
+	// Change the book.
+	book := Book{}
+	if err := change(tx, &book); err != nil {
+		return models.DBError(err)
+	}
+
+	// Define query string.
+	query := `INSERT INTO book_revisions (book_id, revision, created_at, user_id, action, snapshot) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	// Record the changed book.
+	var userID *uuid.UUID
+	if actor != uuid.Nil {
+		userID = &actor
+	}
+	if _, err := tx.Exec(query, book.ID, book.Version, time.Now(), userID, action, Snapshot(book)); err != nil {
+		return models.DBError(err)
+	}
+
+	// Publish the change.
+	if err := enqueue(tx, newEvent(eventType(action), book, actor)); err != nil {
 		return err
 	}
 
-	// This query returns nothing.
-	return nil
+	return models.DBError(tx.Commit())
+}
+
+// enqueue adds the outbox message of the event in the transaction of the change.
+func enqueue(tx *sqlx.Tx, e Event) error {
+	m, err := e.message()
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(tx, m)
 }
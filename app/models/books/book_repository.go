@@ -0,0 +1,39 @@
+package books
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrVersionConflict is returned, if a book was changed or deleted
+// since the version given to UpdateBook, TransitionBook or DeleteBook was read.
+var ErrVersionConflict = errors.New("book was changed by another request")
+
+// BookRepository interface to describe a storage of books.
+// Deleted books are kept in the trash until they are purged.
+// Every change made by an actor (the user ID, uuid.Nil for the system)
+// is recorded as a revision of the book, purging deletes the revisions too.
+// BookQueries is the PostgreSQL implementation, BookMemory keeps books in memory.
+type BookRepository interface {
+	GetBooks(params BookListParams) ([]Book, int, error)
+	GetBooksAfter(params BookListParams, cursor *Cursor) ([]Book, *Cursor, error)
+	GetBook(id uuid.UUID) (Book, error)
+	GetDeletedBook(id uuid.UUID) (Book, error)
+	CreateBook(b *Book, actor uuid.UUID) error
+	UpdateBook(id uuid.UUID, b *Book, actor uuid.UUID) error
+	TransitionBook(id uuid.UUID, b *Book, transition string, actor uuid.UUID) error
+	DeleteBook(id uuid.UUID, version int, actor uuid.UUID) error
+	RestoreBook(id uuid.UUID, actor uuid.UUID) error
+	PurgeBook(id uuid.UUID) error
+	PurgeDeletedBooks(before time.Time) ([]uuid.UUID, error)
+	GetBookRevisions(bookID uuid.UUID, limit, offset int) ([]Revision, int, error)
+	GetBookRevision(bookID uuid.UUID, revision int) (Revision, error)
+}
+
+// Check, that both implementations satisfy the interface.
+var (
+	_ BookRepository = (*BookQueries)(nil)
+	_ BookRepository = (*BookMemory)(nil)
+)
@@ -0,0 +1,127 @@
+package books
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision actions, status changes are recorded with the name
+// of their transition, e.g. "publish".
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// Revision struct to describe a stored state of a book.
+// The revision number is the version of the book after the change.
+type Revision struct {
+	BookID    uuid.UUID  `db:"book_id" json:"book_id"`
+	Revision  int        `db:"revision" json:"revision"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UserID    *uuid.UUID `db:"user_id" json:"user_id"`
+	Action    string     `db:"action" json:"action"`
+	Book      Snapshot   `db:"snapshot" json:"book"`
+}
+
+// Snapshot type to describe a full copy of a book stored as JSONB.
+type Snapshot Book
+
+// Value make the Snapshot type implement the driver.Valuer interface.
+func (s Snapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan make the Snapshot type implement the sql.Scanner interface.
+func (s *Snapshot) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, s)
+}
+
+// Change struct to describe a changed field between two revisions.
+// Fields of book attributes are named like "book_attrs.rating".
+type Change struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// diffIgnored are fields, which change with every revision.
+var diffIgnored = map[string]bool{
+	"updated_at": true,
+	"version":    true,
+}
+
+// DiffRevisions func for getting the fields changed between two revisions.
+func DiffRevisions(from, to *Revision) ([]Change, error) {
+	fromFields, err := flatten(from.Book)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := flatten(to.Book)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect all field names of both revisions.
+	names := []string{}
+	for name := range fromFields {
+		names = append(names, name)
+	}
+	for name := range toFields {
+		if _, ok := fromFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []Change{}
+	for _, name := range names {
+		if diffIgnored[name] || reflect.DeepEqual(fromFields[name], toFields[name]) {
+			continue
+		}
+		changes = append(changes, Change{Field: name, From: fromFields[name], To: toFields[name]})
+	}
+
+	return changes, nil
+}
+
+// flatten returns the JSON fields of a snapshot, nested objects
+// are flattened into dotted field names.
+func flatten(s Snapshot) (map[string]interface{}, error) {
+	j, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	var walk func(prefix string, doc map[string]interface{})
+	walk = func(prefix string, doc map[string]interface{}) {
+		for name, value := range doc {
+			if nested, ok := value.(map[string]interface{}); ok {
+				walk(prefix+name+".", nested)
+				continue
+			}
+			fields[prefix+name] = value
+		}
+	}
+	walk("", doc)
+
+	return fields, nil
+}
@@ -0,0 +1,179 @@
+package books
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status type to describe the lifecycle state of a book.
+// It is stored as a number and written as its name in JSON.
+type Status int
+
+// Book statuses, the numbers are stored in the database.
+const (
+	StatusDraft Status = iota
+	StatusActive
+	StatusArchived
+	StatusWithdrawn
+)
+
+// statusNames are the names of statuses used in JSON and query params.
+var statusNames = map[Status]string{
+	StatusDraft:     "draft",
+	StatusActive:    "active",
+	StatusArchived:  "archived",
+	StatusWithdrawn: "withdrawn",
+}
+
+// Transition struct to describe an action changing the status of a book.
+type Transition struct {
+	Name string
+	From []Status
+	To   Status
+}
+
+// Transitions are all allowed status changes of a book.
+// Withdrawn books can not be changed back.
+var Transitions = []Transition{
+	{Name: "publish", From: []Status{StatusDraft, StatusArchived}, To: StatusActive},
+	{Name: "unpublish", From: []Status{StatusActive}, To: StatusDraft},
+	{Name: "archive", From: []Status{StatusActive}, To: StatusArchived},
+	{Name: "withdraw", From: []Status{StatusDraft, StatusActive, StatusArchived}, To: StatusWithdrawn},
+}
+
+// InitialStatuses are the statuses new books may be created with.
+var InitialStatuses = []Status{StatusDraft, StatusActive}
+
+// ParseStatus func for parsing a status name, or its number
+// used before statuses had names.
+func ParseStatus(s string) (Status, error) {
+	for status, name := range statusNames {
+		if strings.EqualFold(s, name) {
+			return status, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(s); err == nil && Status(n).IsValid() {
+		return Status(n), nil
+	}
+
+	return 0, errors.New("book status must be one of draft, active, archived or withdrawn")
+}
+
+// IsValid method for checking, if the status is known.
+func (s Status) IsValid() bool {
+	_, ok := statusNames[s]
+	return ok
+}
+
+// String method for getting the name of the status.
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// MarshalText method to write the status as its name.
+func (s Status) MarshalText() ([]byte, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("unknown book status %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalJSON method to read the status from its name or number,
+// numbers are still found in revisions stored before statuses had names.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		name = string(data)
+	}
+
+	status, err := ParseStatus(name)
+	if err != nil {
+		return err
+	}
+
+	*s = status
+	return nil
+}
+
+// CanTransition method for checking, if a book may change from status s to the given one.
+// Keeping the status is always allowed.
+func (s Status) CanTransition(to Status) bool {
+	if s == to {
+		return true
+	}
+	for _, t := range Transitions {
+		if t.To == to && t.allows(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTransition func for getting a transition by its name.
+func FindTransition(name string) (Transition, bool) {
+	for _, t := range Transitions {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Transition{}, false
+}
+
+// allows reports whether the transition starts from the given status.
+func (t Transition) allows(from Status) bool {
+	for _, s := range t.From {
+		if s == from {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply method for changing the status of the book by the transition at the given time.
+// It returns a TransitionError, if the book is not in a status the transition starts from.
+func (t Transition) Apply(b *Book, at time.Time) error {
+	if !t.allows(b.BookStatus) {
+		return &TransitionError{Transition: t.Name, From: b.BookStatus, To: t.To}
+	}
+	b.BookStatus = t.To
+	b.StatusChangedAt = &at
+	return nil
+}
+
+// ChangeStatus method for changing the status of the book at the given time,
+// e.g. by a full update. It returns a TransitionError, if no transition
+// leads from the current status to the given one.
+func (b *Book) ChangeStatus(to Status, at time.Time) error {
+	if b.BookStatus == to {
+		return nil
+	}
+	if !b.BookStatus.CanTransition(to) {
+		return &TransitionError{From: b.BookStatus, To: to}
+	}
+	b.BookStatus = to
+	b.StatusChangedAt = &at
+	return nil
+}
+
+// TransitionError struct to describe an invalid status change.
+type TransitionError struct {
+	Transition string // name of the transition, if changed by one
+	From       Status
+	To         Status
+}
+
+// Error method to make TransitionError implement the error interface.
+func (e *TransitionError) Error() string {
+	if e.Transition != "" {
+		return fmt.Sprintf("can not %s book, it is %s", e.Transition, e.From)
+	}
+	return fmt.Sprintf("book status can not change from %s to %s", e.From, e.To)
+}
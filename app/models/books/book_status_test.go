@@ -0,0 +1,140 @@
+package books
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatusCanTransition(t *testing.T) {
+	tests := []struct {
+		from Status
+		to   Status
+		want bool
+	}{
+		{from: StatusDraft, to: StatusDraft, want: true},
+		{from: StatusDraft, to: StatusActive, want: true},
+		{from: StatusDraft, to: StatusArchived, want: false},
+		{from: StatusDraft, to: StatusWithdrawn, want: true},
+		{from: StatusActive, to: StatusDraft, want: true},
+		{from: StatusActive, to: StatusActive, want: true},
+		{from: StatusActive, to: StatusArchived, want: true},
+		{from: StatusActive, to: StatusWithdrawn, want: true},
+		{from: StatusArchived, to: StatusDraft, want: false},
+		{from: StatusArchived, to: StatusActive, want: true},
+		{from: StatusArchived, to: StatusArchived, want: true},
+		{from: StatusArchived, to: StatusWithdrawn, want: true},
+		{from: StatusWithdrawn, to: StatusDraft, want: false},
+		{from: StatusWithdrawn, to: StatusActive, want: false},
+		{from: StatusWithdrawn, to: StatusArchived, want: false},
+		{from: StatusWithdrawn, to: StatusWithdrawn, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from.String()+" to "+tt.to.String(), func(t *testing.T) {
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Errorf("CanTransition() = %v, want %v", got, tt.want)
+			}
+
+			// Changing the status by a full update follows the same table.
+			book := &Book{BookStatus: tt.from}
+			err := book.ChangeStatus(tt.to, time.Now())
+			if (err == nil) != tt.want {
+				t.Errorf("ChangeStatus() error = %v, want allowed %v", err, tt.want)
+			}
+			var transitionErr *TransitionError
+			if err != nil && !errors.As(err, &transitionErr) {
+				t.Errorf("ChangeStatus() error = %T, want *TransitionError", err)
+			}
+		})
+	}
+}
+
+func TestTransitionApply(t *testing.T) {
+	at := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		transition string
+		from       Status
+		want       Status
+		wantErr    bool
+	}{
+		{transition: "publish", from: StatusDraft, want: StatusActive},
+		{transition: "publish", from: StatusArchived, want: StatusActive},
+		{transition: "publish", from: StatusActive, wantErr: true},
+		{transition: "unpublish", from: StatusActive, want: StatusDraft},
+		{transition: "unpublish", from: StatusArchived, wantErr: true},
+		{transition: "archive", from: StatusActive, want: StatusArchived},
+		{transition: "archive", from: StatusDraft, wantErr: true},
+		{transition: "withdraw", from: StatusDraft, want: StatusWithdrawn},
+		{transition: "withdraw", from: StatusArchived, want: StatusWithdrawn},
+		{transition: "withdraw", from: StatusWithdrawn, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transition+" "+tt.from.String(), func(t *testing.T) {
+			transition, ok := FindTransition(tt.transition)
+			if !ok {
+				t.Fatalf("FindTransition(%q) not found", tt.transition)
+			}
+
+			book := &Book{BookStatus: tt.from}
+			err := transition.Apply(book, at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if book.BookStatus != tt.from || book.StatusChangedAt != nil {
+					t.Errorf("Apply() changed the book to %s", book.BookStatus)
+				}
+				return
+			}
+			if book.BookStatus != tt.want || book.StatusChangedAt == nil || !book.StatusChangedAt.Equal(at) {
+				t.Errorf("Apply() = %s at %v, want %s at %v", book.BookStatus, book.StatusChangedAt, tt.want, at)
+			}
+		})
+	}
+
+	if _, ok := FindTransition("delete"); ok {
+		t.Errorf("FindTransition(%q) found, want none", "delete")
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    Status
+		wantErr bool
+	}{
+		{json: `"draft"`, want: StatusDraft},
+		{json: `"Active"`, want: StatusActive},
+		{json: `"archived"`, want: StatusArchived},
+		{json: `3`, want: StatusWithdrawn},
+		{json: `"1"`, want: StatusActive},
+		{json: `"published"`, wantErr: true},
+		{json: `7`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var got Status
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal() = %s, want %s", got, tt.want)
+			}
+
+			// Statuses are written as their names.
+			data, err := json.Marshal(got)
+			if err != nil || string(data) != `"`+tt.want.String()+`"` {
+				t.Errorf("Marshal() = %s, %v, want %q", data, err, tt.want.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+)
+
+// Errors of repositories, independent of the storage. Errors returned by
+// repositories match them with errors.Is and keep the original error.
+var (
+	// ErrNotFound means the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict means the change violates a unique or foreign key constraint.
+	ErrConflict = errors.New("record conflicts with existing data")
+
+	// ErrTransient means the storage failed temporarily and the request
+	// may be retried, e.g. on serialization failures and timeouts.
+	ErrTransient = errors.New("temporary storage failure, retry later")
+)
+
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgLockNotAvailable     = "55P03"
+	pgQueryCanceled        = "57014"
+	pgCannotConnectNow     = "57P03"
+	pgConnectionException  = "08" // class
+)
+
+// Error struct to describe a storage error of a known kind.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error method to make Error implement the error interface.
+func (e *Error) Error() string {
+	if e.Kind == ErrNotFound {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap method for getting the original error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is method for matching the kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// DBError func for translating a database error into a typed storage error.
+// Errors of unknown kinds and nil are returned as they are.
+func DBError(err error) error {
+	var known *Error
+	if err == nil || errors.As(err, &known) {
+		return err
+	}
+
+	kind := kindOf(err)
+	if kind == nil {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// ExpectRows func for returning ErrNotFound, if the statement affected no rows.
+func ExpectRows(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return DBError(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// kindOf returns the kind of a database error or nil, if it is unknown.
+func kindOf(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, driver.ErrBadConn), pgconn.Timeout(err):
+		return ErrTransient
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation, pgForeignKeyViolation:
+		return ErrConflict
+	case pgSerializationFailure, pgDeadlockDetected, pgLockNotAvailable, pgQueryCanceled, pgCannotConnectNow:
+		return ErrTransient
+	}
+	if strings.HasPrefix(pgErr.Code, pgConnectionException) {
+		return ErrTransient
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package files
+
+import (
+	"sort"
+	"sync"
+
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+)
+
+// FileMemory struct for keeping files in memory.
+// It is safe for concurrent use and mirrors the behaviour of FileQueries.
+type FileMemory struct {
+	mu    sync.RWMutex
+	files map[uuid.UUID]File
+}
+
+// NewFileMemory func for creating an empty in-memory file storage.
+func NewFileMemory() *FileMemory {
+	return &FileMemory{files: map[uuid.UUID]File{}}
+}
+
+// GetBookFiles method for getting all files of book by given ID ordered by creation time.
+func (m *FileMemory) GetBookFiles(bookID uuid.UUID) ([]File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	files := []File{}
+	for _, f := range m.files {
+		if f.BookID == bookID {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].CreatedAt.Equal(files[j].CreatedAt) {
+			return files[i].CreatedAt.Before(files[j].CreatedAt)
+		}
+		return files[i].ID.String() < files[j].ID.String()
+	})
+
+	return files, nil
+}
+
+// GetBookFile method for getting one file of book by given IDs.
+func (m *FileMemory) GetBookFile(bookID, id uuid.UUID) (File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[id]
+	if !ok || f.BookID != bookID {
+		return File{}, models.ErrNotFound
+	}
+	return f, nil
+}
+
+// CreateBookFile method for storing a new file.
+func (m *FileMemory) CreateBookFile(f *File) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[f.ID] = *f
+	return nil
+}
+
+// DeleteBookFile method for deleting file of book by given IDs.
+func (m *FileMemory) DeleteBookFile(bookID, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.files[id]
+	if !ok || f.BookID != bookID {
+		return models.ErrNotFound
+	}
+	delete(m.files, id)
+	return nil
+}
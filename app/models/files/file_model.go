@@ -0,0 +1,53 @@
+package files
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Formats of e-book files.
+const (
+	FormatPDF  = "pdf"
+	FormatEPUB = "epub"
+)
+
+// ContentTypes are the content types of the formats.
+var ContentTypes = map[string]string{
+	FormatPDF:  "application/pdf",
+	FormatEPUB: "application/epub+zip",
+}
+
+// File struct to describe an e-book file attached to a book.
+// The content is kept in the blob store, the checksum identifies it.
+type File struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	BookID    uuid.UUID  `db:"book_id" json:"book_id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UserID    *uuid.UUID `db:"user_id" json:"user_id"`
+	Name      string     `db:"name" json:"name"`
+	Format    string     `db:"format" json:"format" enums:"pdf,epub"`
+	Size      int64      `db:"size" json:"size"`
+	SHA256    string     `db:"sha256" json:"sha256"`
+}
+
+// ContentType method for getting the content type of the file.
+func (f *File) ContentType() string {
+	return ContentTypes[f.Format]
+}
+
+// Sniff func for detecting the format of a file by the magic bytes
+// at its start. It returns an empty string, if the format is not supported.
+// EPUB files are ZIP archives starting with an uncompressed "mimetype" entry.
+func Sniff(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return FormatPDF
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")) && len(head) >= 58 &&
+		bytes.Equal(head[30:58], []byte("mimetypeapplication/epub+zip")):
+		return FormatEPUB
+	default:
+		return ""
+	}
+}
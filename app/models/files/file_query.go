@@ -0,0 +1,78 @@
+package files
+
+import (
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// FileQueries struct for queries from File model.
+type FileQueries struct {
+	*sqlx.DB
+}
+
+// GetBookFiles method for getting all files of book by given ID ordered by creation time.
+func (q *FileQueries) GetBookFiles(bookID uuid.UUID) ([]File, error) {
+	// Define files variable.
+	files := []File{}
+
+	// Define query string.
+	query := `SELECT * FROM book_files WHERE book_id = $1 ORDER BY created_at, id`
+
+	// Send query to database.
+	err := q.Select(&files, query, bookID)
+	if err != nil {
+		// Return empty object and error.
+		return files, models.DBError(err)
+	}
+
+	// Return query result.
+	return files, nil
+}
+
+// GetBookFile method for getting one file of book by given IDs.
+func (q *FileQueries) GetBookFile(bookID, id uuid.UUID) (File, error) {
+	// Define file variable.
+	file := File{}
+
+	// Define query string.
+	query := `SELECT * FROM book_files WHERE book_id = $1 AND id = $2`
+
+	// Send query to database.
+	err := q.Get(&file, query, bookID, id)
+	if err != nil {
+		// Return empty object and error.
+		return file, models.DBError(err)
+	}
+
+	// Return query result.
+	return file, nil
+}
+
+// CreateBookFile method for creating file by given File object.
+func (q *FileQueries) CreateBookFile(f *File) error {
+	// Define query string.
+	query := `INSERT INTO book_files (id, book_id, created_at, user_id, name, format, size, sha256) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	// Send query to database.
+	_, err := q.Exec(query, f.ID, f.BookID, f.CreatedAt, f.UserID, f.Name, f.Format, f.Size, f.SHA256)
+
+	// Return only error.
+	return models.DBError(err)
+}
+
+// DeleteBookFile method for deleting file of book by given IDs.
+func (q *FileQueries) DeleteBookFile(bookID, id uuid.UUID) error {
+	// Define query string.
+	query := `DELETE FROM book_files WHERE book_id = $1 AND id = $2`
+
+	// Send query to database.
+	result, err := q.Exec(query, bookID, id)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// Checking, if the file exists.
+	return models.ExpectRows(result)
+}
@@ -0,0 +1,20 @@
+package files
+
+import (
+	"github.com/google/uuid"
+)
+
+// FileRepository interface to describe a storage of the e-book files of books.
+// FileQueries is the PostgreSQL implementation, FileMemory keeps them in memory.
+type FileRepository interface {
+	GetBookFiles(bookID uuid.UUID) ([]File, error)
+	GetBookFile(bookID, id uuid.UUID) (File, error)
+	CreateBookFile(f *File) error
+	DeleteBookFile(bookID, id uuid.UUID) error
+}
+
+// Check, that both implementations satisfy the interface.
+var (
+	_ FileRepository = (*FileQueries)(nil)
+	_ FileRepository = (*FileMemory)(nil)
+)
@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyMemory struct for keeping records in memory.
+// It is safe for concurrent use and mirrors the behaviour of IdempotencyQueries.
+type IdempotencyMemory struct {
+	mu      sync.Mutex
+	records map[memoryKey]Record
+}
+
+// memoryKey is the primary key of a record.
+type memoryKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+// NewIdempotencyMemory func for creating an empty in-memory record storage.
+func NewIdempotencyMemory() *IdempotencyMemory {
+	return &IdempotencyMemory{records: map[memoryKey]Record{}}
+}
+
+// ReserveIdempotencyKey method for storing r as a request in progress.
+// Expired records of the caller and key are replaced, unexpired ones are returned.
+func (m *IdempotencyMemory) ReserveIdempotencyKey(r *Record) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := memoryKey{r.UserID, r.Key}
+	if existing, ok := m.records[k]; ok && existing.ExpiresAt.After(r.CreatedAt) {
+		return &existing, nil
+	}
+
+	m.records[k] = Record{
+		UserID:      r.UserID,
+		Key:         r.Key,
+		RequestHash: r.RequestHash,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+
+	return nil, nil
+}
+
+// CompleteIdempotencyKey method for storing the response of a reserved request.
+func (m *IdempotencyMemory) CompleteIdempotencyKey(r *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := memoryKey{r.UserID, r.Key}
+	if record, ok := m.records[k]; ok {
+		record.Status = r.Status
+		record.Headers = r.Headers
+		record.Body = append([]byte(nil), r.Body...)
+		m.records[k] = record
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey method for deleting a request in progress.
+func (m *IdempotencyMemory) ReleaseIdempotencyKey(userID uuid.UUID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := memoryKey{userID, key}
+	if record, ok := m.records[k]; ok && !record.Completed() {
+		delete(m.records, k)
+	}
+
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys method for deleting records expired before the given time.
+// It returns the number of purged records.
+func (m *IdempotencyMemory) PurgeExpiredIdempotencyKeys(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for k, record := range m.records {
+		if record.ExpiresAt.Before(before) {
+			delete(m.records, k)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,54 @@
+package idempotency
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record struct to describe a request made with an Idempotency-Key
+// and the response stored to replay it on retries.
+type Record struct {
+	UserID      uuid.UUID `db:"user_id"`
+	Key         string    `db:"key"`
+	RequestHash string    `db:"request_hash"`
+	Status      int       `db:"status"` // 0 while the request is in progress
+	Headers     Headers   `db:"headers"`
+	Body        []byte    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+// Completed method for checking, if the response of the request is stored.
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+// Headers type to describe stored response headers.
+type Headers map[string]string
+
+// Value make the Headers type implement the driver.Valuer interface.
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+// Scan make the Headers type implement the sql.Scanner interface.
+func (h *Headers) Scan(value interface{}) error {
+	if value == nil {
+		*h = nil
+		return nil
+	}
+
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, h)
+}
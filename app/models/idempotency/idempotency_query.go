@@ -0,0 +1,103 @@
+package idempotency
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyQueries struct for queries from Record model.
+type IdempotencyQueries struct {
+	*sqlx.DB
+}
+
+// ReserveIdempotencyKey method for storing r as a request in progress.
+// Expired records of the caller and key are replaced, unexpired ones are returned.
+func (q *IdempotencyQueries) ReserveIdempotencyKey(r *Record) (*Record, error) {
+	// Define query strings.
+	insert := `INSERT INTO idempotency_keys (user_id, key, request_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = 0, headers = NULL, body = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+	query := `SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	// Retry once, if the stored record was released between both statements.
+	for attempt := 0; attempt < 2; attempt++ {
+		// Send query to database.
+		result, err := q.Exec(insert, r.UserID, r.Key, r.RequestHash, r.CreatedAt, r.ExpiresAt)
+		if err != nil {
+			// Return only error.
+			return nil, models.DBError(err)
+		}
+
+		// Checking, if the key is reserved.
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, models.DBError(err)
+		}
+		if n > 0 {
+			return nil, nil
+		}
+
+		// Get the stored record.
+		existing := Record{}
+		err = q.Get(&existing, query, r.UserID, r.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			// Return empty object and error.
+			return nil, models.DBError(err)
+		}
+
+		// Return query result.
+		return &existing, nil
+	}
+
+	return nil, models.ErrTransient
+}
+
+// CompleteIdempotencyKey method for storing the response of a reserved request.
+func (q *IdempotencyQueries) CompleteIdempotencyKey(r *Record) error {
+	// Define query string.
+	query := `UPDATE idempotency_keys SET status = $3, headers = $4, body = $5 WHERE user_id = $1 AND key = $2`
+
+	// Send query to database.
+	_, err := q.Exec(query, r.UserID, r.Key, r.Status, r.Headers, r.Body)
+
+	// Return only error.
+	return models.DBError(err)
+}
+
+// ReleaseIdempotencyKey method for deleting a request in progress.
+func (q *IdempotencyQueries) ReleaseIdempotencyKey(userID uuid.UUID, key string) error {
+	// Define query string.
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status = 0`
+
+	// Send query to database.
+	_, err := q.Exec(query, userID, key)
+
+	// Return only error.
+	return models.DBError(err)
+}
+
+// PurgeExpiredIdempotencyKeys method for deleting records expired before the given time.
+// It returns the number of purged records.
+func (q *IdempotencyQueries) PurgeExpiredIdempotencyKeys(before time.Time) (int, error) {
+	// Define query string.
+	query := `DELETE FROM idempotency_keys WHERE expires_at < $1`
+
+	// Send query to database.
+	result, err := q.Exec(query, before)
+	if err != nil {
+		// Return only error.
+		return 0, models.DBError(err)
+	}
+
+	// Return number of purged records.
+	n, err := result.RowsAffected()
+	return int(n), models.DBError(err)
+}
@@ -0,0 +1,32 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRepository interface to describe a storage of requests made
+// with an Idempotency-Key, keyed by the caller (user ID) and the key.
+// IdempotencyQueries is the PostgreSQL implementation, IdempotencyMemory keeps records in memory.
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey stores r as a request in progress. If an unexpired
+	// record of the caller and key exists, nothing is stored and it is returned.
+	ReserveIdempotencyKey(r *Record) (*Record, error)
+
+	// CompleteIdempotencyKey stores the response of a reserved request.
+	CompleteIdempotencyKey(r *Record) error
+
+	// ReleaseIdempotencyKey deletes a request in progress, so it can be retried.
+	ReleaseIdempotencyKey(userID uuid.UUID, key string) error
+
+	// PurgeExpiredIdempotencyKeys deletes records expired before the given time
+	// and returns their number.
+	PurgeExpiredIdempotencyKeys(before time.Time) (int, error)
+}
+
+// Check, that both implementations satisfy the interface.
+var (
+	_ IdempotencyRepository = (*IdempotencyQueries)(nil)
+	_ IdempotencyRepository = (*IdempotencyMemory)(nil)
+)
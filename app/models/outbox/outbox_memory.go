@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+)
+
+// OutboxMemory struct for keeping messages in memory.
+// It is safe for concurrent use and mirrors the behaviour of OutboxQueries.
+type OutboxMemory struct {
+	mu       sync.Mutex
+	messages []Message // ordered by ID
+	nextID   int64
+}
+
+// NewOutboxMemory func for creating an empty in-memory message storage.
+func NewOutboxMemory() *OutboxMemory {
+	return &OutboxMemory{nextID: 1}
+}
+
+// Enqueue method for adding a message. In-memory storages call it
+// while holding the lock of the change it describes.
+func (m *OutboxMemory) Enqueue(msg *Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msg.ID = m.nextID
+	m.nextID++
+	m.messages = append(m.messages, *msg)
+}
+
+// ClaimOutboxMessages method for getting due messages, which are the oldest
+// unpublished message of their aggregate, ordered by ID.
+func (m *OutboxMemory) ClaimOutboxMessages(now time.Time, lease time.Duration, limit int) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	type aggregate struct {
+		typ string
+		id  uuid.UUID
+	}
+
+	messages := []Message{}
+	waiting := map[aggregate]bool{}
+	for i := range m.messages {
+		msg := &m.messages[i]
+		if msg.PublishedAt != nil {
+			continue
+		}
+
+		// Skip later messages of an aggregate with an unpublished message.
+		a := aggregate{msg.AggregateType, msg.AggregateID}
+		if waiting[a] {
+			continue
+		}
+		waiting[a] = true
+
+		if msg.NextAttemptAt.After(now) || len(messages) >= limit {
+			continue
+		}
+
+		// Lease claimed message.
+		msg.NextAttemptAt = now.Add(lease)
+		messages = append(messages, *msg)
+	}
+
+	return messages, nil
+}
+
+// UpdateOutboxMessage method for storing the result of an attempt to publish a message.
+func (m *OutboxMemory) UpdateOutboxMessage(msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.messages {
+		if m.messages[i].ID == msg.ID {
+			m.messages[i].Attempts = msg.Attempts
+			m.messages[i].NextAttemptAt = msg.NextAttemptAt
+			m.messages[i].LastError = msg.LastError
+			m.messages[i].PublishedAt = msg.PublishedAt
+			return nil
+		}
+	}
+
+	return models.ErrNotFound
+}
+
+// GetOutboxMessagesAfter method for getting messages with an ID greater than
+// the given one, published or not, ordered by ID.
+func (m *OutboxMemory) GetOutboxMessagesAfter(id int64, limit int) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := []Message{}
+	for _, msg := range m.messages {
+		if msg.ID > id && len(messages) < limit {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+// LastOutboxMessageID method for getting the ID of the newest message.
+func (m *OutboxMemory) LastOutboxMessageID() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.nextID - 1, nil
+}
+
+// PurgePublishedOutboxMessages method for deleting messages published before the given time.
+// It returns the number of purged messages.
+func (m *OutboxMemory) PurgePublishedOutboxMessages(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.messages[:0]
+	for _, msg := range m.messages {
+		if msg.PublishedAt == nil || !msg.PublishedAt.Before(before) {
+			kept = append(kept, msg)
+		}
+	}
+	purged := len(m.messages) - len(kept)
+	m.messages = kept
+
+	return purged, nil
+}
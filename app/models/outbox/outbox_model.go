@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message struct to describe a domain event waiting to be published.
+// Messages are written in the transaction of the change they describe
+// and published in order of their ID per aggregate.
+type Message struct {
+	ID            int64           `db:"id" json:"id"`
+	EventID       uuid.UUID       `db:"event_id" json:"event_id"`
+	EventType     string          `db:"event_type" json:"event_type"`
+	AggregateType string          `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID   uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
+	Payload       json.RawMessage `db:"payload" json:"payload"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     *string         `db:"last_error" json:"last_error"`
+	PublishedAt   *time.Time      `db:"published_at" json:"published_at"`
+}
+
+// NewMessage func for creating a message of an event, the payload is its JSON.
+func NewMessage(eventID uuid.UUID, eventType, aggregateType string, aggregateID uuid.UUID, event interface{}) (*Message, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Message{
+		EventID:       eventID,
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
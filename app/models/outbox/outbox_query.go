@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"time"
+
+	"fiber-api-example/app/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// OutboxQueries struct for queries from Message model.
+type OutboxQueries struct {
+	*sqlx.DB
+}
+
+// Enqueue func for adding a message in the transaction of the change it describes,
+// so the message is stored, if and only if the change is committed.
+func Enqueue(tx sqlx.Execer, m *Message) error {
+	// Define query string.
+	query := `INSERT INTO outbox (event_id, event_type, aggregate_type, aggregate_id, payload, created_at, next_attempt_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	// Send query to database.
+	_, err := tx.Exec(query, m.EventID, m.EventType, m.AggregateType, m.AggregateID, m.Payload, m.CreatedAt, m.NextAttemptAt)
+
+	// Return only error.
+	return models.DBError(err)
+}
+
+// ClaimOutboxMessages method for getting due messages, which are the oldest unpublished
+// message of their aggregate, ordered by ID. Rows claimed by concurrent relays are skipped.
+func (q *OutboxQueries) ClaimOutboxMessages(now time.Time, lease time.Duration, limit int) ([]Message, error) {
+	// Define messages variable.
+	messages := []Message{}
+
+	// Define query string.
+	query := `WITH claimed AS (
+			UPDATE outbox SET next_attempt_at = $2 WHERE id IN (
+				SELECT o.id FROM outbox o WHERE o.published_at IS NULL AND o.next_attempt_at <= $1
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox p WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+					AND p.published_at IS NULL AND p.id < o.id
+				)
+				ORDER BY o.id LIMIT $3 FOR UPDATE SKIP LOCKED
+			) RETURNING *
+		) SELECT * FROM claimed ORDER BY id`
+
+	// Send query to database.
+	err := q.Select(&messages, query, now, now.Add(lease), limit)
+	if err != nil {
+		// Return empty object and error.
+		return messages, models.DBError(err)
+	}
+
+	// Return query result.
+	return messages, nil
+}
+
+// UpdateOutboxMessage method for storing the result of an attempt to publish a message.
+func (q *OutboxQueries) UpdateOutboxMessage(m *Message) error {
+	// Define query string.
+	query := `UPDATE outbox SET attempts = $2, next_attempt_at = $3, last_error = $4, published_at = $5 WHERE id = $1`
+
+	// Send query to database.
+	result, err := q.Exec(query, m.ID, m.Attempts, m.NextAttemptAt, m.LastError, m.PublishedAt)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// Checking, if the message exists.
+	return models.ExpectRows(result)
+}
+
+// GetOutboxMessagesAfter method for getting messages with an ID greater than
+// the given one, published or not, ordered by ID.
+func (q *OutboxQueries) GetOutboxMessagesAfter(id int64, limit int) ([]Message, error) {
+	// Define messages variable.
+	messages := []Message{}
+
+	// Define query string.
+	query := `SELECT * FROM outbox WHERE id > $1 ORDER BY id LIMIT $2`
+
+	// Send query to database.
+	err := q.Select(&messages, query, id, limit)
+	if err != nil {
+		// Return empty object and error.
+		return messages, models.DBError(err)
+	}
+
+	// Return query result.
+	return messages, nil
+}
+
+// LastOutboxMessageID method for getting the ID of the newest message.
+func (q *OutboxQueries) LastOutboxMessageID() (int64, error) {
+	// Define ID variable.
+	var id int64
+
+	// Send query to database.
+	err := q.Get(&id, `SELECT COALESCE(MAX(id), 0) FROM outbox`)
+
+	// Return query result.
+	return id, models.DBError(err)
+}
+
+// PurgePublishedOutboxMessages method for deleting messages published before the given time.
+// It returns the number of purged messages.
+func (q *OutboxQueries) PurgePublishedOutboxMessages(before time.Time) (int, error) {
+	// Define query string.
+	query := `DELETE FROM outbox WHERE published_at < $1`
+
+	// Send query to database.
+	result, err := q.Exec(query, before)
+	if err != nil {
+		// Return only error.
+		return 0, models.DBError(err)
+	}
+
+	// Return number of purged messages.
+	n, err := result.RowsAffected()
+	return int(n), models.DBError(err)
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"time"
+)
+
+// OutboxRepository interface to describe a storage of messages to publish.
+// Messages are added by the repositories of the changed models, see Enqueue.
+// OutboxQueries is the PostgreSQL implementation, OutboxMemory keeps messages in memory.
+type OutboxRepository interface {
+	// ClaimOutboxMessages returns up to limit unpublished messages due at the
+	// given time, which are the oldest unpublished message of their aggregate.
+	// They are not due again for the lease, so concurrent relays do not publish
+	// them twice and later messages of the aggregate wait for them.
+	ClaimOutboxMessages(now time.Time, lease time.Duration, limit int) ([]Message, error)
+
+	// UpdateOutboxMessage stores the result of an attempt to publish a message.
+	UpdateOutboxMessage(m *Message) error
+
+	// GetOutboxMessagesAfter returns up to limit messages with an ID greater
+	// than the given one, published or not, ordered by ID. Every instance
+	// reads all messages with it, e.g. to stream them to its clients.
+	GetOutboxMessagesAfter(id int64, limit int) ([]Message, error)
+
+	// LastOutboxMessageID returns the ID of the newest message, zero if there is none.
+	LastOutboxMessageID() (int64, error)
+
+	// PurgePublishedOutboxMessages deletes messages published before the given time
+	// and returns their number.
+	PurgePublishedOutboxMessages(before time.Time) (int, error)
+}
+
+// Check, that both implementations satisfy the interface.
+var (
+	_ OutboxRepository = (*OutboxQueries)(nil)
+	_ OutboxRepository = (*OutboxMemory)(nil)
+)
@@ -0,0 +1,45 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CommandUsage describes the arguments of the users command.
+const CommandUsage = "usage: users promote|demote EMAIL"
+
+// RunCommand func for executing a users command with the given arguments,
+// e.g. to promote the first admin, since only admins may change roles.
+func RunCommand(repo UserRepository, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return errors.New(CommandUsage)
+	}
+
+	var role string
+	switch args[0] {
+	case "promote":
+		role = RoleAdmin
+	case "demote":
+		role = RoleUser
+	default:
+		return errors.New(CommandUsage)
+	}
+
+	// Get user by email.
+	user, err := repo.GetUserByEmail(args[1])
+	if err != nil {
+		return fmt.Errorf("can't find user %q: %w", args[1], err)
+	}
+
+	// Update role of the user.
+	user.UserRole = role
+	user.UpdatedAt = time.Now()
+	if err := repo.UpdateUser(user.ID, &user); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "User %s (%s) is now %s\n", user.Email, user.ID, role)
+	return err
+}
@@ -0,0 +1,142 @@
+package users
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+)
+
+// errDuplicateEmail mirrors the unique index on users.email.
+var errDuplicateEmail = &models.Error{
+	Kind: models.ErrConflict,
+	Err:  errors.New("user with this email already exists"),
+}
+
+// UserMemory struct for keeping users in memory.
+// It is safe for concurrent use and mirrors the behaviour of UserQueries.
+type UserMemory struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]User
+}
+
+// NewUserMemory func for creating an empty in-memory user storage.
+func NewUserMemory() *UserMemory {
+	return &UserMemory{users: map[uuid.UUID]User{}}
+}
+
+// GetUsers method for getting a page of users ordered by creation time.
+func (m *UserMemory) GetUsers(limit, offset int) ([]User, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID.String() < users[j].ID.String()
+	})
+
+	// Cut requested page.
+	total := len(users)
+	if offset >= total {
+		return []User{}, total, nil
+	}
+	users = users[offset:]
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, total, nil
+}
+
+// GetUser method for getting one user by given ID.
+func (m *UserMemory) GetUser(id uuid.UUID) (User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return User{}, models.ErrNotFound
+	}
+
+	return user, nil
+}
+
+// GetUserByEmail method for getting one user by given email, ignoring case.
+func (m *UserMemory) GetUserByEmail(email string) (User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+
+	return User{}, models.ErrNotFound
+}
+
+// CreateUser method for creating user by given User object.
+func (m *UserMemory) CreateUser(u *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.emailTaken(u.Email, u.ID) {
+		return errDuplicateEmail
+	}
+	m.users[u.ID] = *u
+
+	return nil
+}
+
+// UpdateUser method for updating user by given User object.
+func (m *UserMemory) UpdateUser(id uuid.UUID, u *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		// Like an UPDATE without matching rows, this is not an error.
+		return nil
+	}
+	if m.emailTaken(u.Email, id) {
+		return errDuplicateEmail
+	}
+
+	user.UpdatedAt = u.UpdatedAt
+	user.Email = u.Email
+	user.UserStatus = u.UserStatus
+	user.UserRole = u.UserRole
+	m.users[id] = user
+
+	return nil
+}
+
+// DeleteUser method for delete user by given ID.
+func (m *UserMemory) DeleteUser(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, id)
+
+	return nil
+}
+
+// emailTaken reports whether another user has the given email.
+// The caller must hold the lock.
+func (m *UserMemory) emailTaken(email string, id uuid.UUID) bool {
+	for _, u := range m.users {
+		if u.ID != id && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package users
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User roles.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User struct to describe user object.
+type User struct {
+	ID           uuid.UUID `db:"id" json:"id" validate:"required,uuid"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	Email        string    `db:"email" json:"email" validate:"required,email,lte=255"`
+	UserStatus   int       `db:"user_status" json:"user_status" validate:"min=0,max=1"` // 0 == blocked, 1 == active
+	UserRole     string    `db:"user_role" json:"user_role" validate:"required,oneof=user admin"`
+	PasswordHash string    `db:"password_hash" json:"-"`
+}
+
+// Credentials struct to describe login data of a user.
+type Credentials struct {
+	Email    string `json:"email" validate:"required,email,lte=255"`
+	Password string `json:"password" validate:"required,gte=8,lte=72"`
+}
@@ -0,0 +1,125 @@
+package users
+
+import (
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// UserQueries struct for queries from User model.
+type UserQueries struct {
+	*sqlx.DB
+}
+
+// GetUsers method for getting a page of users ordered by creation time.
+// It also returns the total number of users.
+func (q *UserQueries) GetUsers(limit, offset int) ([]User, int, error) {
+	// Define users and total variables.
+	users := []User{}
+	total := 0
+
+	// Count all users.
+	if err := q.Get(&total, `SELECT count(*) FROM users`); err != nil {
+		// Return empty object and error.
+		return users, 0, models.DBError(err)
+	}
+
+	// Define query string.
+	query := `SELECT * FROM users ORDER BY created_at, id LIMIT $1 OFFSET $2`
+
+	// Send query to database.
+	err := q.Select(&users, query, limit, offset)
+	if err != nil {
+		// Return empty object and error.
+		return users, 0, models.DBError(err)
+	}
+
+	// Return query result.
+	return users, total, nil
+}
+
+// GetUser method for getting one user by given ID.
+func (q *UserQueries) GetUser(id uuid.UUID) (User, error) {
+	// Define user variable.
+	user := User{}
+
+	// Define query string.
+	query := `SELECT * FROM users WHERE id = $1`
+
+	// Send query to database.
+	err := q.Get(&user, query, id)
+	if err != nil {
+		// Return empty object and error.
+		return user, models.DBError(err)
+	}
+
+	// Return query result.
+	return user, nil
+}
+
+// GetUserByEmail method for getting one user by given email, ignoring case.
+func (q *UserQueries) GetUserByEmail(email string) (User, error) {
+	// Define user variable.
+	user := User{}
+
+	// Define query string.
+	query := `SELECT * FROM users WHERE lower(email) = lower($1)`
+
+	// Send query to database.
+	err := q.Get(&user, query, email)
+	if err != nil {
+		// Return empty object and error.
+		return user, models.DBError(err)
+	}
+
+	// Return query result.
+	return user, nil
+}
+
+// CreateUser method for creating user by given User object.
+func (q *UserQueries) CreateUser(u *User) error {
+	// Define query string.
+	query := `INSERT INTO users (id, created_at, updated_at, email, user_status, user_role, password_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	// Send query to database.
+	_, err := q.Exec(query, u.ID, u.CreatedAt, u.UpdatedAt, u.Email, u.UserStatus, u.UserRole, u.PasswordHash)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// This query returns nothing.
+	return nil
+}
+
+// UpdateUser method for updating user by given User object.
+func (q *UserQueries) UpdateUser(id uuid.UUID, u *User) error {
+	// Define query string.
+	query := `UPDATE users SET updated_at = $2, email = $3, user_status = $4, user_role = $5 WHERE id = $1`
+
+	// Send query to database.
+	_, err := q.Exec(query, id, u.UpdatedAt, u.Email, u.UserStatus, u.UserRole)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// This query returns nothing.
+	return nil
+}
+
+// DeleteUser method for delete user by given ID.
+func (q *UserQueries) DeleteUser(id uuid.UUID) error {
+	// Define query string.
+	query := `DELETE FROM users WHERE id = $1`
+
+	// Send query to database.
+	_, err := q.Exec(query, id)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// This query returns nothing.
+	return nil
+}
@@ -0,0 +1,20 @@
+package users
+
+import "github.com/google/uuid"
+
+// UserRepository interface to describe a storage of users.
+// UserQueries is the PostgreSQL implementation, UserMemory keeps users in memory.
+type UserRepository interface {
+	GetUsers(limit, offset int) ([]User, int, error)
+	GetUser(id uuid.UUID) (User, error)
+	GetUserByEmail(email string) (User, error)
+	CreateUser(u *User) error
+	UpdateUser(id uuid.UUID, u *User) error
+	DeleteUser(id uuid.UUID) error
+}
+
+// Check, that both implementations satisfy the interface.
+var (
+	_ UserRepository = (*UserQueries)(nil)
+	_ UserRepository = (*UserMemory)(nil)
+)
@@ -0,0 +1,279 @@
+package webhooks
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+)
+
+// WebhookMemory struct for keeping endpoints, deliveries and attempts in memory.
+// It is safe for concurrent use and mirrors the behaviour of WebhookQueries.
+type WebhookMemory struct {
+	mu         sync.RWMutex
+	endpoints  map[uuid.UUID]Endpoint
+	deliveries map[uuid.UUID]Delivery
+	attempts   map[uuid.UUID][]Attempt
+}
+
+// NewWebhookMemory func for creating an empty in-memory webhook storage.
+func NewWebhookMemory() *WebhookMemory {
+	return &WebhookMemory{
+		endpoints:  map[uuid.UUID]Endpoint{},
+		deliveries: map[uuid.UUID]Delivery{},
+		attempts:   map[uuid.UUID][]Attempt{},
+	}
+}
+
+// GetWebhookEndpoints method for getting a page of endpoints ordered by creation time.
+func (m *WebhookMemory) GetWebhookEndpoints(limit, offset int) ([]Endpoint, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	endpoints := make([]Endpoint, 0, len(m.endpoints))
+	for _, e := range m.endpoints {
+		endpoints = append(endpoints, e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if !endpoints[i].CreatedAt.Equal(endpoints[j].CreatedAt) {
+			return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+		}
+		return endpoints[i].ID.String() < endpoints[j].ID.String()
+	})
+
+	// Cut requested page.
+	total := len(endpoints)
+	if offset >= total {
+		return []Endpoint{}, total, nil
+	}
+	endpoints = endpoints[offset:]
+	if len(endpoints) > limit {
+		endpoints = endpoints[:limit]
+	}
+
+	return endpoints, total, nil
+}
+
+// GetWebhookEndpoint method for getting one endpoint by given ID.
+func (m *WebhookMemory) GetWebhookEndpoint(id uuid.UUID) (Endpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	endpoint, ok := m.endpoints[id]
+	if !ok {
+		return Endpoint{}, models.ErrNotFound
+	}
+
+	return endpoint, nil
+}
+
+// CreateWebhookEndpoint method for creating endpoint by given Endpoint object.
+func (m *WebhookMemory) CreateWebhookEndpoint(e *Endpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.endpoints[e.ID] = *e
+
+	return nil
+}
+
+// UpdateWebhookEndpoint method for updating endpoint by given Endpoint object.
+func (m *WebhookMemory) UpdateWebhookEndpoint(id uuid.UUID, e *Endpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	endpoint, ok := m.endpoints[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+
+	endpoint.UpdatedAt = e.UpdatedAt
+	endpoint.URL = e.URL
+	endpoint.Secret = e.Secret
+	endpoint.Events = e.Events
+	endpoint.Description = e.Description
+	endpoint.Active = e.Active
+	m.endpoints[id] = endpoint
+
+	return nil
+}
+
+// DeleteWebhookEndpoint method for deleting endpoint by given ID with its deliveries.
+func (m *WebhookMemory) DeleteWebhookEndpoint(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.endpoints[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(m.endpoints, id)
+
+	// Cascade to deliveries and their attempts.
+	for deliveryID, d := range m.deliveries {
+		if d.EndpointID == id {
+			delete(m.deliveries, deliveryID)
+			delete(m.attempts, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+// CreateWebhookDeliveries method for storing pending deliveries of an event
+// to all active endpoints subscribing to its type, which have none of it yet.
+func (m *WebhookMemory) CreateWebhookDeliveries(eventID uuid.UUID, eventType string, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Collect endpoints, which have a delivery of the event.
+	delivered := map[uuid.UUID]bool{}
+	for _, d := range m.deliveries {
+		if d.EventID == eventID {
+			delivered[d.EndpointID] = true
+		}
+	}
+
+	now := time.Now()
+	for _, e := range m.endpoints {
+		if !e.Subscribes(eventType) || delivered[e.ID] {
+			continue
+		}
+		id := uuid.New()
+		m.deliveries[id] = Delivery{
+			ID:            id,
+			EndpointID:    e.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       append([]byte(nil), payload...),
+			Status:        DeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries method for getting due deliveries, which are not due
+// again for the lease.
+func (m *WebhookMemory) ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deliveries := []Delivery{}
+	for _, d := range m.deliveries {
+		if (d.Status == DeliveryPending || d.Status == DeliveryFailed) && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(*deliveries[j].NextAttemptAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	// Lease claimed deliveries.
+	leased := now.Add(lease)
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = &leased
+		m.deliveries[deliveries[i].ID] = deliveries[i]
+	}
+
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt method for storing an attempt and the resulting state of its delivery.
+func (m *WebhookMemory) RecordWebhookAttempt(d *Delivery, a *Attempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, ok := m.deliveries[d.ID]
+	if !ok {
+		return models.ErrNotFound
+	}
+
+	delivery.Status = d.Status
+	delivery.Attempts = d.Attempts
+	delivery.NextAttemptAt = d.NextAttemptAt
+	delivery.LastError = d.LastError
+	delivery.UpdatedAt = d.UpdatedAt
+	m.deliveries[d.ID] = delivery
+	m.attempts[d.ID] = append(m.attempts[d.ID], *a)
+
+	return nil
+}
+
+// GetWebhookDeliveries method for getting a page of deliveries to an endpoint, the latest first.
+func (m *WebhookMemory) GetWebhookDeliveries(endpointID uuid.UUID, limit, offset int) ([]Delivery, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	deliveries := []Delivery{}
+	for _, d := range m.deliveries {
+		if d.EndpointID == endpointID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID.String() < deliveries[j].ID.String()
+	})
+
+	// Cut requested page.
+	total := len(deliveries)
+	if offset >= total {
+		return []Delivery{}, total, nil
+	}
+	deliveries = deliveries[offset:]
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, total, nil
+}
+
+// GetWebhookDelivery method for getting one delivery to an endpoint by given ID.
+func (m *WebhookMemory) GetWebhookDelivery(endpointID, id uuid.UUID) (Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	delivery, ok := m.deliveries[id]
+	if !ok || delivery.EndpointID != endpointID {
+		return Delivery{}, models.ErrNotFound
+	}
+
+	return delivery, nil
+}
+
+// GetWebhookAttempts method for getting all attempts of a delivery, the first first.
+func (m *WebhookMemory) GetWebhookAttempts(deliveryID uuid.UUID) ([]Attempt, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]Attempt{}, m.attempts[deliveryID]...), nil
+}
+
+// RetryWebhookDelivery method for making a delivery pending again.
+func (m *WebhookMemory) RetryWebhookDelivery(endpointID, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, ok := m.deliveries[id]
+	if !ok || delivery.EndpointID != endpointID {
+		return models.ErrNotFound
+	}
+
+	now := time.Now()
+	delivery.Status = DeliveryPending
+	delivery.NextAttemptAt = &now
+	delivery.UpdatedAt = now
+	m.deliveries[id] = delivery
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package webhooks
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses. Failed deliveries are retried with backoff,
+// until they succeed or run out of attempts and become dead.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+	DeliveryDead      = "dead"
+)
+
+// Endpoint struct to describe a webhook subscription.
+// Events of the listed types are delivered to the URL, signed with the secret.
+type Endpoint struct {
+	ID          uuid.UUID  `db:"id" json:"id" validate:"required,uuid"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	UserID      *uuid.UUID `db:"user_id" json:"user_id"`
+	URL         string     `db:"url" json:"url" validate:"required,url,lte=2048"`
+	Secret      string     `db:"secret" json:"secret,omitempty" validate:"lte=255"`
+	Events      EventTypes `db:"events" json:"events" swaggertype:"array,string"`
+	Description string     `db:"description" json:"description" validate:"lte=255"`
+	Active      bool       `db:"active" json:"active"`
+}
+
+// Subscribes method for checking, if the endpoint receives events of the given type.
+// An endpoint without event types receives all events.
+func (e *Endpoint) Subscribes(eventType string) bool {
+	if !e.Active {
+		return false
+	}
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// EventTypes type to describe the event types an endpoint subscribes to.
+type EventTypes []string
+
+// Value make the EventTypes type implement the driver.Valuer interface.
+func (t EventTypes) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan make the EventTypes type implement the sql.Scanner interface.
+func (t *EventTypes) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(j, t)
+}
+
+// Delivery struct to describe an event sent to an endpoint.
+// The payload is kept to send the same body on every attempt.
+type Delivery struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	EndpointID    uuid.UUID       `db:"endpoint_id" json:"endpoint_id"`
+	EventID       uuid.UUID       `db:"event_id" json:"event_id"`
+	EventType     string          `db:"event_type" json:"event_type"`
+	Payload       json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	Status        string          `db:"status" json:"status"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time      `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     *string         `db:"last_error" json:"last_error"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Attempt struct to describe one try to send a delivery, kept for debugging.
+// ResponseStatus is nil, if no response was received.
+type Attempt struct {
+	DeliveryID     uuid.UUID `db:"delivery_id" json:"delivery_id"`
+	Attempt        int       `db:"attempt" json:"attempt"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	DurationMS     int       `db:"duration_ms" json:"duration_ms"`
+	ResponseStatus *int      `db:"response_status" json:"response_status"`
+	ResponseBody   *string   `db:"response_body" json:"response_body"`
+	Error          *string   `db:"error" json:"error"`
+}
+
+// Succeeded method for checking, if the endpoint accepted the delivery.
+func (a *Attempt) Succeeded() bool {
+	return a.ResponseStatus != nil && *a.ResponseStatus >= 200 && *a.ResponseStatus < 300
+}
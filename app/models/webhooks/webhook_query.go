@@ -0,0 +1,247 @@
+package webhooks
+
+import (
+	"time"
+
+	"fiber-api-example/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// WebhookQueries struct for queries from webhook models.
+type WebhookQueries struct {
+	*sqlx.DB
+}
+
+// GetWebhookEndpoints method for getting a page of endpoints ordered by creation time.
+// It also returns the total number of endpoints.
+func (q *WebhookQueries) GetWebhookEndpoints(limit, offset int) ([]Endpoint, int, error) {
+	// Define endpoints and total variables.
+	endpoints := []Endpoint{}
+	total := 0
+
+	// Count all endpoints.
+	if err := q.Get(&total, `SELECT count(*) FROM webhook_endpoints`); err != nil {
+		// Return empty object and error.
+		return endpoints, 0, models.DBError(err)
+	}
+
+	// Define query string.
+	query := `SELECT * FROM webhook_endpoints ORDER BY created_at, id LIMIT $1 OFFSET $2`
+
+	// Send query to database.
+	err := q.Select(&endpoints, query, limit, offset)
+	if err != nil {
+		// Return empty object and error.
+		return endpoints, 0, models.DBError(err)
+	}
+
+	// Return query result.
+	return endpoints, total, nil
+}
+
+// GetWebhookEndpoint method for getting one endpoint by given ID.
+func (q *WebhookQueries) GetWebhookEndpoint(id uuid.UUID) (Endpoint, error) {
+	// Define endpoint variable.
+	endpoint := Endpoint{}
+
+	// Define query string.
+	query := `SELECT * FROM webhook_endpoints WHERE id = $1`
+
+	// Send query to database.
+	err := q.Get(&endpoint, query, id)
+	if err != nil {
+		// Return empty object and error.
+		return endpoint, models.DBError(err)
+	}
+
+	// Return query result.
+	return endpoint, nil
+}
+
+// CreateWebhookEndpoint method for creating endpoint by given Endpoint object.
+func (q *WebhookQueries) CreateWebhookEndpoint(e *Endpoint) error {
+	// Define query string.
+	query := `INSERT INTO webhook_endpoints (id, created_at, updated_at, user_id, url, secret, events, description, active) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	// Send query to database.
+	_, err := q.Exec(query, e.ID, e.CreatedAt, e.UpdatedAt, e.UserID, e.URL, e.Secret, e.Events, e.Description, e.Active)
+
+	// Return only error.
+	return models.DBError(err)
+}
+
+// UpdateWebhookEndpoint method for updating endpoint by given Endpoint object.
+func (q *WebhookQueries) UpdateWebhookEndpoint(id uuid.UUID, e *Endpoint) error {
+	// Define query string.
+	query := `UPDATE webhook_endpoints SET updated_at = $2, url = $3, secret = $4, events = $5, description = $6, active = $7 WHERE id = $1`
+
+	// Send query to database.
+	result, err := q.Exec(query, id, e.UpdatedAt, e.URL, e.Secret, e.Events, e.Description, e.Active)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// Checking, if the endpoint exists.
+	return models.ExpectRows(result)
+}
+
+// DeleteWebhookEndpoint method for deleting endpoint by given ID with its deliveries.
+func (q *WebhookQueries) DeleteWebhookEndpoint(id uuid.UUID) error {
+	// Define query string.
+	query := `DELETE FROM webhook_endpoints WHERE id = $1`
+
+	// Send query to database.
+	result, err := q.Exec(query, id)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// Checking, if the endpoint exists.
+	return models.ExpectRows(result)
+}
+
+// CreateWebhookDeliveries method for storing pending deliveries of an event
+// to all active endpoints subscribing to its type, which have none of it yet.
+func (q *WebhookQueries) CreateWebhookDeliveries(eventID uuid.UUID, eventType string, payload []byte) error {
+	// Define query string.
+	query := `INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT uuid_generate_v4(), id, $1, $2, $3, $4, now(), now(), now() FROM webhook_endpoints
+		WHERE active AND (events = '[]'::jsonb OR events @> jsonb_build_array($2::text))
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING`
+
+	// Send query to database.
+	_, err := q.Exec(query, eventID, eventType, payload, DeliveryPending)
+
+	// Return only error.
+	return models.DBError(err)
+}
+
+// ClaimWebhookDeliveries method for getting due deliveries, which are not due
+// again for the lease. Rows claimed by concurrent dispatchers are skipped.
+func (q *WebhookQueries) ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	// Define deliveries variable.
+	deliveries := []Delivery{}
+
+	// Define query string.
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $2 WHERE id IN (
+			SELECT id FROM webhook_deliveries WHERE status IN ($3, $4) AND next_attempt_at <= $1
+			ORDER BY next_attempt_at LIMIT $5 FOR UPDATE SKIP LOCKED
+		) RETURNING *`
+
+	// Send query to database.
+	err := q.Select(&deliveries, query, now, now.Add(lease), DeliveryPending, DeliveryFailed, limit)
+	if err != nil {
+		// Return empty object and error.
+		return deliveries, models.DBError(err)
+	}
+
+	// Return query result.
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt method for storing an attempt and the resulting state of its delivery.
+func (q *WebhookQueries) RecordWebhookAttempt(d *Delivery, a *Attempt) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return models.DBError(err)
+	}
+	defer tx.Rollback()
+
+	// Define query strings.
+	insert := `INSERT INTO webhook_attempts (delivery_id, attempt, created_at, duration_ms, response_status, response_body, error) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	update := `UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, updated_at = $6 WHERE id = $1`
+
+	// Send queries to database.
+	if _, err := tx.Exec(insert, a.DeliveryID, a.Attempt, a.CreatedAt, a.DurationMS, a.ResponseStatus, a.ResponseBody, a.Error); err != nil {
+		return models.DBError(err)
+	}
+	if _, err := tx.Exec(update, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.UpdatedAt); err != nil {
+		return models.DBError(err)
+	}
+
+	return models.DBError(tx.Commit())
+}
+
+// GetWebhookDeliveries method for getting a page of deliveries to an endpoint,
+// the latest first. It also returns the total number of deliveries.
+func (q *WebhookQueries) GetWebhookDeliveries(endpointID uuid.UUID, limit, offset int) ([]Delivery, int, error) {
+	// Define deliveries and total variables.
+	deliveries := []Delivery{}
+	total := 0
+
+	// Count deliveries to the endpoint.
+	if err := q.Get(&total, `SELECT count(*) FROM webhook_deliveries WHERE endpoint_id = $1`, endpointID); err != nil {
+		// Return empty object and error.
+		return deliveries, 0, models.DBError(err)
+	}
+
+	// Define query string.
+	query := `SELECT * FROM webhook_deliveries WHERE endpoint_id = $1 ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`
+
+	// Send query to database.
+	err := q.Select(&deliveries, query, endpointID, limit, offset)
+	if err != nil {
+		// Return empty object and error.
+		return deliveries, 0, models.DBError(err)
+	}
+
+	// Return query result.
+	return deliveries, total, nil
+}
+
+// GetWebhookDelivery method for getting one delivery to an endpoint by given ID.
+func (q *WebhookQueries) GetWebhookDelivery(endpointID, id uuid.UUID) (Delivery, error) {
+	// Define delivery variable.
+	delivery := Delivery{}
+
+	// Define query string.
+	query := `SELECT * FROM webhook_deliveries WHERE endpoint_id = $1 AND id = $2`
+
+	// Send query to database.
+	err := q.Get(&delivery, query, endpointID, id)
+	if err != nil {
+		// Return empty object and error.
+		return delivery, models.DBError(err)
+	}
+
+	// Return query result.
+	return delivery, nil
+}
+
+// GetWebhookAttempts method for getting all attempts of a delivery, the first first.
+func (q *WebhookQueries) GetWebhookAttempts(deliveryID uuid.UUID) ([]Attempt, error) {
+	// Define attempts variable.
+	attempts := []Attempt{}
+
+	// Define query string.
+	query := `SELECT * FROM webhook_attempts WHERE delivery_id = $1 ORDER BY attempt`
+
+	// Send query to database.
+	err := q.Select(&attempts, query, deliveryID)
+	if err != nil {
+		// Return empty object and error.
+		return attempts, models.DBError(err)
+	}
+
+	// Return query result.
+	return attempts, nil
+}
+
+// RetryWebhookDelivery method for making a delivery pending again.
+func (q *WebhookQueries) RetryWebhookDelivery(endpointID, id uuid.UUID) error {
+	// Define query string.
+	query := `UPDATE webhook_deliveries SET status = $3, next_attempt_at = now(), updated_at = now() WHERE endpoint_id = $1 AND id = $2`
+
+	// Send query to database.
+	result, err := q.Exec(query, endpointID, id, DeliveryPending)
+	if err != nil {
+		// Return only error.
+		return models.DBError(err)
+	}
+
+	// Checking, if the delivery exists.
+	return models.ExpectRows(result)
+}
@@ -0,0 +1,43 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRepository interface to describe a storage of webhook endpoints,
+// their deliveries and the attempts to send them.
+// WebhookQueries is the PostgreSQL implementation, WebhookMemory keeps them in memory.
+type WebhookRepository interface {
+	GetWebhookEndpoints(limit, offset int) ([]Endpoint, int, error)
+	GetWebhookEndpoint(id uuid.UUID) (Endpoint, error)
+	CreateWebhookEndpoint(e *Endpoint) error
+	UpdateWebhookEndpoint(id uuid.UUID, e *Endpoint) error
+	DeleteWebhookEndpoint(id uuid.UUID) error
+
+	// CreateWebhookDeliveries stores pending deliveries of an event
+	// to all active endpoints subscribing to its type, which have none of it yet.
+	CreateWebhookDeliveries(eventID uuid.UUID, eventType string, payload []byte) error
+
+	// ClaimWebhookDeliveries returns up to limit pending or failed deliveries,
+	// which are due at the given time. They are not due again for the lease,
+	// so concurrent dispatchers do not send them twice.
+	ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+
+	// RecordWebhookAttempt stores an attempt and the resulting state of its delivery.
+	RecordWebhookAttempt(d *Delivery, a *Attempt) error
+
+	GetWebhookDeliveries(endpointID uuid.UUID, limit, offset int) ([]Delivery, int, error)
+	GetWebhookDelivery(endpointID, id uuid.UUID) (Delivery, error)
+	GetWebhookAttempts(deliveryID uuid.UUID) ([]Attempt, error)
+
+	// RetryWebhookDelivery makes a delivery pending again, e.g. a dead one.
+	RetryWebhookDelivery(endpointID, id uuid.UUID) error
+}
+
+// Check, that both implementations satisfy the interface.
+var (
+	_ WebhookRepository = (*WebhookQueries)(nil)
+	_ WebhookRepository = (*WebhookMemory)(nil)
+)
@@ -1,19 +1,36 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/files"
+	"fiber-api-example/app/models/idempotency"
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/models/users"
+	"fiber-api-example/app/models/webhooks"
+	"fiber-api-example/app/platform/migrations"
 	"fiber-api-example/app/utils/logger"
 	_ "github.com/jackc/pgx/v4/stdlib" // load pgx driver for PostgreSQL
 	"github.com/jmoiron/sqlx"
 	"github.com/spf13/viper"
+	"io"
+	"net"
+	"net/url"
 	"strconv"
-	"strings"
 	"time"
 )
 
 // Queries struct for collect all app queries.
 type Queries struct {
-	*books.BookQueries // load queries from Book model
+	books.BookRepository              // load queries from Book model
+	users.UserRepository              // load queries from User model
+	idempotency.IdempotencyRepository // load queries from idempotency Record model
+	webhooks.WebhookRepository        // load queries from webhook models
+	outbox.OutboxRepository           // load queries from outbox Message model
+	files.FileRepository              // load queries from File model
+
+	db *sqlx.DB
 }
 
 type DatabaseConfig struct {
@@ -23,31 +40,186 @@ type DatabaseConfig struct {
 	Password string
 	Port     int
 	Database string
+
+	// Connection pool limits.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ConnectTimeout is the total time to wait for the database on startup.
+	// ConnectBackoff is the initial delay between attempts, it doubles up
+	// to ConnectBackoffMax.
+	ConnectTimeout    time.Duration
+	ConnectBackoff    time.Duration
+	ConnectBackoffMax time.Duration
 }
 
 func (cfg *DatabaseConfig) ConnectString() string {
-	s := []string{cfg.Username, ":", cfg.Password, "@", cfg.Host, ":", strconv.Itoa(cfg.Port), "/", cfg.Database}
-	return strings.Join(s, "")
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.Username, cfg.Password),
+		Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:   cfg.Database,
+	}
+	return u.String()
 }
 
-func Connection() (*Queries, error) {
-	config := DatabaseConfig{
-		Driver:   "postgres",
-		Host:     viper.GetString("DB"),
-		Username: viper.GetString("DB_USERNAME"),
-		Password: viper.GetString("DB_PASSWORD"),
-		Port:     viper.GetInt("DB_PORT"),
-		Database: viper.GetString("DB_DATABASE"),
+// GetDatabaseConfig func for reading database configuration.
+func GetDatabaseConfig() DatabaseConfig {
+	return DatabaseConfig{
+		Driver:            viper.GetString("DB_DRIVER"),
+		Host:              viper.GetString("DB_HOST"),
+		Username:          viper.GetString("DB_USERNAME"),
+		Password:          viper.GetString("DB_PASSWORD"),
+		Port:              viper.GetInt("DB_PORT"),
+		Database:          viper.GetString("DB_DATABASE"),
+		MaxOpenConns:      viper.GetInt("DB_MAX_OPEN_CONNS"),
+		MaxIdleConns:      viper.GetInt("DB_MAX_IDLE_CONNS"),
+		ConnMaxLifetime:   viper.GetDuration("DB_CONN_MAX_LIFETIME"),
+		ConnMaxIdleTime:   viper.GetDuration("DB_CONN_MAX_IDLE_TIME"),
+		ConnectTimeout:    viper.GetDuration("DB_CONNECT_TIMEOUT"),
+		ConnectBackoff:    viper.GetDuration("DB_CONNECT_BACKOFF"),
+		ConnectBackoffMax: viper.GetDuration("DB_CONNECT_BACKOFF_MAX"),
 	}
-	db, err := sqlx.Open("pgx", config.ConnectString())
+}
+
+// New func for creating the process-wide database connection pool.
+// It waits until the database is reachable or the connect timeout expires.
+// With DB_DRIVER=memory all data is kept in memory and no database is used.
+func New() (*Queries, error) {
+	config := GetDatabaseConfig()
+
+	if config.Driver == "memory" {
+		return NewMemory(), nil
+	}
+
+	db, err := Open(config)
 	if err != nil {
-		logger.Error(err, "Can't connect to "+config.Database)
 		return nil, err
 	}
-	db.SetConnMaxLifetime(time.Minute * 5)
+
+	// Apply pending migrations, if enabled.
+	if viper.GetBool("DB_MIGRATE_ON_STARTUP") {
+		m, err := migrations.NewMigrator(db)
+		if err == nil {
+			err = m.Up()
+		}
+		if err != nil {
+			logger.Error(err, "Can't migrate "+config.Database)
+			_ = db.Close()
+			return nil, err
+		}
+	}
 
 	return &Queries{
 		// Set queries from models:
-		BookQueries: &books.BookQueries{DB: db}, // from Book model
+		BookRepository:        &books.BookQueries{DB: db},              // from Book model
+		UserRepository:        &users.UserQueries{DB: db},              // from User model
+		IdempotencyRepository: &idempotency.IdempotencyQueries{DB: db}, // from idempotency Record model
+		WebhookRepository:     &webhooks.WebhookQueries{DB: db},        // from webhook models
+		OutboxRepository:      &outbox.OutboxQueries{DB: db},           // from outbox Message model
+		FileRepository:        &files.FileQueries{DB: db},              // from File model
+
+		db: db,
 	}, nil
 }
+
+// RunMigrateCommand func for executing a migrate command on the configured database.
+// The connection pool is opened without migrating on startup, so commands
+// like down and goto are not preceded by applying all pending migrations.
+func RunMigrateCommand(args []string, out io.Writer) error {
+	config := GetDatabaseConfig()
+	if config.Driver == "memory" {
+		return errors.New("migrations require the postgres driver")
+	}
+
+	db, err := Open(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return migrations.RunCommand(db, args, out)
+}
+
+// NewMemory func for creating queries backed by in-memory storages.
+// Books publish their changes to the outbox while holding their lock.
+func NewMemory() *Queries {
+	messages := outbox.NewOutboxMemory()
+
+	return &Queries{
+		// Set in-memory storages for models:
+		BookRepository:        books.NewBookMemory(messages),      // for Book model
+		UserRepository:        users.NewUserMemory(),              // for User model
+		IdempotencyRepository: idempotency.NewIdempotencyMemory(), // for idempotency Record model
+		WebhookRepository:     webhooks.NewWebhookMemory(),        // for webhook models
+		OutboxRepository:      messages,                           // for outbox Message model
+		FileRepository:        files.NewFileMemory(),              // for File model
+	}
+}
+
+// Open func for opening a connection pool and waiting for the database.
+func Open(config DatabaseConfig) (*sqlx.DB, error) {
+	db, err := sqlx.Open("pgx", config.ConnectString())
+	if err != nil {
+		logger.Error(err, "Can't connect to "+config.Database)
+		return nil, err
+	}
+
+	// Set connection pool limits.
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if err := waitForDatabase(db, config); err != nil {
+		logger.Error(err, "Can't connect to "+config.Database)
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// waitForDatabase pings the database with exponential backoff
+// until it responds or the connect timeout expires.
+func waitForDatabase(db *sqlx.DB, config DatabaseConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ConnectTimeout)
+	defer cancel()
+
+	backoff := config.ConnectBackoff
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		logger.Info("Database is not reachable, retrying in ", backoff, ": ", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > config.ConnectBackoffMax {
+			backoff = config.ConnectBackoffMax
+		}
+	}
+}
+
+// DB method for getting the underlying connection pool.
+// It returns nil for the in-memory driver.
+func (q *Queries) DB() *sqlx.DB {
+	return q.db
+}
+
+// Close method for closing the connection pool.
+func (q *Queries) Close() error {
+	if q.db == nil {
+		return nil
+	}
+	return q.db.Close()
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"fiber-api-example/app/models/outbox"
+	"github.com/spf13/viper"
+)
+
+// ErrDraining is returned by Subscribe, after the broadcaster was drained.
+var ErrDraining = errors.New("broadcaster is draining")
+
+type BroadcasterConfig struct {
+	// History is the number of recent messages kept to resume subscriptions.
+	History int
+
+	// Buffer is the number of messages a subscriber may fall behind,
+	// slower subscribers are dropped.
+	Buffer int
+}
+
+// GetBroadcasterConfig func for reading stream configuration.
+func GetBroadcasterConfig() BroadcasterConfig {
+	return BroadcasterConfig{
+		History: viper.GetInt("STREAM_HISTORY"),
+		Buffer:  viper.GetInt("STREAM_BUFFER"),
+	}
+}
+
+// Broadcaster struct for passing messages from a channel, e.g. of a ChannelPublisher,
+// to all subscribers. It never blocks on subscribers: a subscriber, whose buffer
+// is full, is dropped and may resume from the last received message, as long as
+// it is among the recent messages.
+type Broadcaster struct {
+	source <-chan outbox.Message
+	config BroadcasterConfig
+
+	mu          sync.Mutex
+	recent      []outbox.Message // oldest first
+	subscribers map[*Subscription]struct{}
+	draining    bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Subscription struct to describe a subscriber of a broadcaster.
+type Subscription struct {
+	// Replay are the messages after the last received one to send first.
+	// Reset tells, that the last received message is unknown, so messages
+	// may have been missed and the subscriber must reload its state.
+	Replay []outbox.Message
+	Reset  bool
+
+	messages   chan outbox.Message
+	overflowed bool
+}
+
+// Messages method for getting the channel of new messages.
+// It is closed, when the subscription ends.
+func (s *Subscription) Messages() <-chan outbox.Message {
+	return s.messages
+}
+
+// Overflowed method for checking, if the subscription ended, because
+// the subscriber was too slow. It is valid after Messages is closed.
+func (s *Subscription) Overflowed() bool {
+	return s.overflowed
+}
+
+// NewBroadcaster func for creating a broadcaster of the messages of the source.
+func NewBroadcaster(source <-chan outbox.Message, config BroadcasterConfig) *Broadcaster {
+	return &Broadcaster{
+		source:      source,
+		config:      config,
+		subscribers: map[*Subscription]struct{}{},
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start method for starting the broadcaster in background.
+func (b *Broadcaster) Start() {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		for {
+			select {
+			case m := <-b.source:
+				b.broadcast(m)
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Drain method for ending all subscriptions and refusing new ones,
+// so streaming connections end before the server shuts down.
+func (b *Broadcaster) Drain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.draining = true
+	for s := range b.subscribers {
+		b.end(s, false)
+	}
+}
+
+// Close method for stopping the broadcaster.
+func (b *Broadcaster) Close() error {
+	b.Drain()
+	close(b.stop)
+	b.wg.Wait()
+	return nil
+}
+
+// Subscribe method for receiving the messages after the one with the given ID,
+// or new messages only, if the ID is empty.
+func (b *Broadcaster) Subscribe(lastID string) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.draining {
+		return nil, ErrDraining
+	}
+
+	s := &Subscription{messages: make(chan outbox.Message, b.config.Buffer)}
+	if lastID != "" {
+		s.Reset = true
+		for i := range b.recent {
+			if strconv.FormatInt(b.recent[i].ID, 10) == lastID {
+				s.Replay = append([]outbox.Message{}, b.recent[i+1:]...)
+				s.Reset = false
+				break
+			}
+		}
+	}
+	b.subscribers[s] = struct{}{}
+
+	return s, nil
+}
+
+// Unsubscribe method for ending a subscription.
+func (b *Broadcaster) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		b.end(s, false)
+	}
+}
+
+// broadcast keeps the message and sends it to all subscribers,
+// subscribers with a full buffer are dropped.
+func (b *Broadcaster) broadcast(m outbox.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.recent = append(b.recent, m)
+	if len(b.recent) > b.config.History {
+		b.recent = append(b.recent[:0], b.recent[len(b.recent)-b.config.History:]...)
+	}
+
+	for s := range b.subscribers {
+		select {
+		case s.messages <- m:
+		default:
+			b.end(s, true)
+		}
+	}
+}
+
+// end closes the subscription. The caller must hold the lock.
+func (b *Broadcaster) end(s *Subscription, overflowed bool) {
+	s.overflowed = overflowed
+	close(s.messages)
+	delete(b.subscribers, s)
+}
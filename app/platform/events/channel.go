@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+
+	"fiber-api-example/app/models/outbox"
+)
+
+// ChannelPublisher struct for passing messages to an in-process consumer.
+// Publishing blocks until the consumer receives the message or the buffer has room.
+type ChannelPublisher struct {
+	messages chan outbox.Message
+}
+
+// NewChannelPublisher func for creating a publisher with the given buffer size.
+func NewChannelPublisher(buffer int) *ChannelPublisher {
+	return &ChannelPublisher{messages: make(chan outbox.Message, buffer)}
+}
+
+// Publish method for sending the message to the channel.
+// It fails, if the context is done first, so the message is retried.
+func (p *ChannelPublisher) Publish(ctx context.Context, m outbox.Message) error {
+	select {
+	case p.messages <- m:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Messages method for getting the channel to receive the published messages from.
+func (p *ChannelPublisher) Messages() <-chan outbox.Message {
+	return p.messages
+}
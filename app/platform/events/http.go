@@ -0,0 +1,59 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+)
+
+// Headers of posted messages, the body is the payload.
+const (
+	HeaderEventID       = "Event-Id"
+	HeaderEventType     = "Event-Type"
+	HeaderAggregateType = "Aggregate-Type"
+	HeaderAggregateID   = "Aggregate-Id"
+)
+
+// HTTPPublisher struct for posting messages to a URL, e.g. of a message broker.
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPublisher func for creating a publisher posting to the URL.
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish method for posting the message, responses other than 2xx fail.
+func (p *HTTPPublisher) Publish(ctx context.Context, m outbox.Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(m.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, m.EventID.String())
+	req.Header.Set(HeaderEventType, m.EventType)
+	req.Header.Set(HeaderAggregateType, m.AggregateType)
+	req.Header.Set(HeaderAggregateID, m.AggregateID.String())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", p.url, resp.StatusCode)
+	}
+	return nil
+}
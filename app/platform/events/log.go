@@ -0,0 +1,28 @@
+package events
+
+import (
+	"context"
+
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/utils/logger"
+)
+
+// LogPublisher struct for writing messages to the log, e.g. for debugging.
+type LogPublisher struct{}
+
+// NewLogPublisher func for creating a publisher writing to the log.
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+// Publish method for logging the message.
+func (p *LogPublisher) Publish(_ context.Context, m outbox.Message) error {
+	logger.GetLogger().Infow("Published event",
+		"event_id", m.EventID,
+		"event_type", m.EventType,
+		"aggregate_type", m.AggregateType,
+		"aggregate_id", m.AggregateID,
+		"payload", string(m.Payload),
+	)
+	return nil
+}
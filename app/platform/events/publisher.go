@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+	"github.com/spf13/viper"
+)
+
+// Publisher interface to describe a receiver of outbox messages.
+// Messages are published at least once, so publishers must tolerate
+// duplicates, e.g. by the event ID. Returning an error retries the message.
+type Publisher interface {
+	Publish(ctx context.Context, m outbox.Message) error
+}
+
+// Publishers type to publish messages to all of several publishers.
+// A failing publisher retries the message for all of them.
+type Publishers []Publisher
+
+// Publish method for publishing the message to all publishers in order.
+func (p Publishers) Publish(ctx context.Context, m outbox.Message) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Config struct {
+	// Log enables logging published messages.
+	Log bool
+
+	// HTTPURL is the URL messages are posted to, empty disables posting.
+	HTTPURL     string
+	HTTPTimeout time.Duration
+}
+
+// GetConfig func for reading publisher configuration.
+func GetConfig() Config {
+	return Config{
+		Log:         viper.GetBool("OUTBOX_PUBLISH_LOG"),
+		HTTPURL:     viper.GetString("OUTBOX_PUBLISH_HTTP_URL"),
+		HTTPTimeout: viper.GetDuration("OUTBOX_PUBLISH_HTTP_TIMEOUT"),
+	}
+}
+
+// New func for creating the publisher of the configuration,
+// which also publishes to the given in-process publishers.
+func New(config Config, publishers ...Publisher) Publisher {
+	if config.Log {
+		publishers = append(publishers, NewLogPublisher())
+	}
+	if config.HTTPURL != "" {
+		publishers = append(publishers, NewHTTPPublisher(config.HTTPURL, config.HTTPTimeout))
+	}
+	return Publishers(publishers)
+}
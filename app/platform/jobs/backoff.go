@@ -0,0 +1,16 @@
+package jobs
+
+import "time"
+
+// backoff returns the delay before the next attempt after the given number
+// of attempts, it starts with base and doubles up to max.
+func backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models/idempotency"
+	"fiber-api-example/app/utils/logger"
+)
+
+// IdempotencyPurger struct for deleting expired idempotency keys.
+// It runs in background until closed.
+type IdempotencyPurger struct {
+	records  idempotency.IdempotencyRepository
+	interval time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewIdempotencyPurger func for creating a purger of expired idempotency keys.
+// A zero interval disables purging.
+func NewIdempotencyPurger(repo idempotency.IdempotencyRepository, interval time.Duration) *IdempotencyPurger {
+	return &IdempotencyPurger{
+		records:  repo,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start method for starting the purger in background.
+func (p *IdempotencyPurger) Start() {
+	if p.interval <= 0 {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.purge()
+
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close method for stopping the purger and waiting for a running purge.
+func (p *IdempotencyPurger) Close() error {
+	close(p.stop)
+	p.wg.Wait()
+	return nil
+}
+
+// purge deletes expired idempotency keys.
+func (p *IdempotencyPurger) purge() {
+	purged, err := p.records.PurgeExpiredIdempotencyKeys(time.Now())
+	if err != nil {
+		logger.Error("Can't purge expired idempotency keys: ", err)
+		return
+	}
+	if purged > 0 {
+		logger.Info("Purged expired idempotency keys: ", purged)
+	}
+}
@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/platform/events"
+	"fiber-api-example/app/utils/logger"
+	"github.com/spf13/viper"
+)
+
+type OutboxRelayConfig struct {
+	// PollInterval is the time between checks for unpublished messages, zero disables the relay.
+	// BatchSize is the number of messages published per check.
+	PollInterval time.Duration
+	BatchSize    int
+
+	// Timeout is the time to wait for the publisher per message.
+	Timeout time.Duration
+
+	// The delay before a retry starts with Backoff and doubles up to BackoffMax.
+	// Messages are retried until published.
+	Backoff    time.Duration
+	BackoffMax time.Duration
+
+	// Retention is the time published messages are kept, zero keeps them forever.
+	Retention time.Duration
+}
+
+// GetOutboxRelayConfig func for reading outbox configuration.
+func GetOutboxRelayConfig() OutboxRelayConfig {
+	return OutboxRelayConfig{
+		PollInterval: viper.GetDuration("OUTBOX_POLL_INTERVAL"),
+		BatchSize:    viper.GetInt("OUTBOX_BATCH_SIZE"),
+		Timeout:      viper.GetDuration("OUTBOX_PUBLISH_TIMEOUT"),
+		Backoff:      viper.GetDuration("OUTBOX_BACKOFF"),
+		BackoffMax:   viper.GetDuration("OUTBOX_BACKOFF_MAX"),
+		Retention:    viper.GetDuration("OUTBOX_RETENTION"),
+	}
+}
+
+// OutboxRelay struct for publishing the messages of the outbox.
+// Messages of an aggregate are published one after another in order of their ID,
+// at least once: a message may be published again, if the relay stops before
+// marking it as published. It runs in background until closed.
+type OutboxRelay struct {
+	messages  outbox.OutboxRepository
+	publisher events.Publisher
+	config    OutboxRelayConfig
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewOutboxRelay func for creating a relay of outbox messages to the publisher.
+func NewOutboxRelay(repo outbox.OutboxRepository, publisher events.Publisher, config OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		messages:  repo,
+		publisher: publisher,
+		config:    config,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start method for starting the relay in background.
+func (r *OutboxRelay) Start() {
+	if r.config.PollInterval <= 0 {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			r.relay()
+			r.purge()
+
+			select {
+			case <-ticker.C:
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close method for stopping the relay and waiting for running publishes.
+func (r *OutboxRelay) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+	return nil
+}
+
+// relay publishes due messages, until none are left. Every claim returns
+// the next message of each aggregate, whose previous message is published.
+func (r *OutboxRelay) relay() {
+	for {
+		lease := r.config.Timeout * time.Duration(r.config.BatchSize+1)
+		messages, err := r.messages.ClaimOutboxMessages(time.Now(), lease, r.config.BatchSize)
+		if err != nil {
+			logger.Error("Can't claim outbox messages: ", err)
+			return
+		}
+		if len(messages) == 0 {
+			return
+		}
+
+		for i := range messages {
+			r.publish(&messages[i])
+		}
+
+		// Stop, if the relay is closed.
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+	}
+}
+
+// publish passes the message to the publisher and records the result.
+func (r *OutboxRelay) publish(m *outbox.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.Timeout)
+	defer cancel()
+
+	err := r.publisher.Publish(ctx, *m)
+	now := time.Now()
+	m.Attempts++
+	if err != nil {
+		msg := err.Error()
+		m.LastError = &msg
+		m.NextAttemptAt = now.Add(backoff(r.config.Backoff, r.config.BackoffMax, m.Attempts))
+		logger.Error("Can't publish outbox message ", m.ID, ": ", err)
+	} else {
+		m.LastError = nil
+		m.PublishedAt = &now
+	}
+
+	if err := r.messages.UpdateOutboxMessage(m); err != nil {
+		logger.Error("Can't update outbox message: ", err)
+	}
+}
+
+// purge deletes published messages older than the retention period.
+func (r *OutboxRelay) purge() {
+	if r.config.Retention <= 0 {
+		return
+	}
+
+	purged, err := r.messages.PurgePublishedOutboxMessages(time.Now().Add(-r.config.Retention))
+	if err != nil {
+		logger.Error("Can't purge published outbox messages: ", err)
+		return
+	}
+	if purged > 0 {
+		logger.Debug("Purged published outbox messages: ", purged)
+	}
+}
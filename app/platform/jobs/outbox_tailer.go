@@ -0,0 +1,171 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/platform/events"
+	"fiber-api-example/app/utils/logger"
+	"github.com/spf13/viper"
+)
+
+type OutboxTailerConfig struct {
+	// PollInterval is the time between checks for new messages, zero disables the tailer.
+	// BatchSize is the number of messages read per check.
+	PollInterval time.Duration
+	BatchSize    int
+
+	// GapTimeout is the time to wait for a missing message ID. IDs are taken
+	// before the transaction adding the message commits, so a gap may be
+	// filled later. Gaps of rolled back transactions are skipped after it.
+	GapTimeout time.Duration
+}
+
+// GetOutboxTailerConfig func for reading stream configuration.
+func GetOutboxTailerConfig() OutboxTailerConfig {
+	return OutboxTailerConfig{
+		PollInterval: viper.GetDuration("STREAM_POLL_INTERVAL"),
+		BatchSize:    viper.GetInt("STREAM_BATCH_SIZE"),
+		GapTimeout:   viper.GetDuration("STREAM_GAP_TIMEOUT"),
+	}
+}
+
+// OutboxTailer struct for passing every new message of the outbox to a publisher
+// of this instance, e.g. the stream of its clients. Unlike the relay, which
+// publishes every message once, all instances read all messages by their own
+// cursor. It starts after the newest message and runs in background until closed.
+type OutboxTailer struct {
+	messages  outbox.OutboxRepository
+	publisher events.Publisher
+	config    OutboxTailerConfig
+
+	cursor   int64
+	gapSince time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewOutboxTailer func for creating a tailer of outbox messages to the publisher.
+func NewOutboxTailer(repo outbox.OutboxRepository, publisher events.Publisher, config OutboxTailerConfig) *OutboxTailer {
+	return &OutboxTailer{
+		messages:  repo,
+		publisher: publisher,
+		config:    config,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start method for starting the tailer in background.
+func (t *OutboxTailer) Start() {
+	if t.config.PollInterval <= 0 {
+		return
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.config.PollInterval)
+		defer ticker.Stop()
+
+		// Start after the newest message, retrying until the storage answers.
+		for {
+			cursor, err := t.messages.LastOutboxMessageID()
+			if err == nil {
+				t.cursor = cursor
+				break
+			}
+			logger.Error("Can't read last outbox message: ", err)
+
+			select {
+			case <-ticker.C:
+			case <-t.stop:
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-t.stop:
+				return
+			}
+
+			t.tail()
+		}
+	}()
+}
+
+// Close method for stopping the tailer.
+func (t *OutboxTailer) Close() error {
+	close(t.stop)
+	t.wg.Wait()
+	return nil
+}
+
+// tail publishes the messages after the cursor, until none are left
+// or a gap of message IDs must be waited for.
+func (t *OutboxTailer) tail() {
+	for {
+		messages, err := t.messages.GetOutboxMessagesAfter(t.cursor, t.config.BatchSize)
+		if err != nil {
+			logger.Error("Can't read outbox messages: ", err)
+			return
+		}
+
+		for i := range messages {
+			if !t.next(messages[i].ID) {
+				return
+			}
+			if !t.publish(messages[i]) {
+				return
+			}
+			t.cursor = messages[i].ID
+		}
+		if len(messages) < t.config.BatchSize {
+			return
+		}
+	}
+}
+
+// next checks, if the message with the given ID may be published now.
+// A message after a gap waits until the gap is older than the gap timeout.
+func (t *OutboxTailer) next(id int64) bool {
+	if id == t.cursor+1 || t.cursor == 0 {
+		t.gapSince = time.Time{}
+		return true
+	}
+
+	if t.gapSince.IsZero() {
+		t.gapSince = time.Now()
+	}
+	if time.Since(t.gapSince) < t.config.GapTimeout {
+		return false
+	}
+
+	logger.Debug("Skipped outbox message IDs before ", id)
+	t.gapSince = time.Time{}
+	return true
+}
+
+// publish passes the message to the publisher, until it is taken or the tailer is closed.
+func (t *OutboxTailer) publish(m outbox.Message) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := t.publisher.Publish(ctx, m); err != nil {
+		logger.Error("Can't stream outbox message ", m.ID, ": ", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/platform/events"
+)
+
+// tailedOutbox struct for serving messages by ID to a tailer.
+type tailedOutbox struct {
+	outbox.OutboxRepository
+	messages []outbox.Message
+}
+
+func (o *tailedOutbox) add(ids ...int64) {
+	for _, id := range ids {
+		o.messages = append(o.messages, outbox.Message{ID: id})
+	}
+	sort.Slice(o.messages, func(i, j int) bool { return o.messages[i].ID < o.messages[j].ID })
+}
+
+func (o *tailedOutbox) GetOutboxMessagesAfter(id int64, limit int) ([]outbox.Message, error) {
+	messages := []outbox.Message{}
+	for _, m := range o.messages {
+		if m.ID > id && len(messages) < limit {
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+// received returns the IDs of the messages published to the channel so far.
+func received(p *events.ChannelPublisher) []int64 {
+	ids := []int64{}
+	for {
+		select {
+		case m := <-p.Messages():
+			ids = append(ids, m.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestOutboxTailerGaps(t *testing.T) {
+	repo := &tailedOutbox{}
+	stream := events.NewChannelPublisher(10)
+	tailer := NewOutboxTailer(repo, stream, OutboxTailerConfig{BatchSize: 2, GapTimeout: time.Hour})
+	tailer.cursor = 3
+
+	// Message 5 is not committed yet, message 6 waits for it.
+	repo.add(4, 6, 7)
+	tailer.tail()
+	if got, want := received(stream), []int64{4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+
+	repo.add(5)
+	tailer.tail()
+	if got, want := received(stream), []int64{5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+
+	// Gaps of rolled back messages are skipped after the gap timeout.
+	tailer.config.GapTimeout = 0
+	repo.add(9)
+	tailer.tail()
+	if got, want := received(stream), []int64{9}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+}
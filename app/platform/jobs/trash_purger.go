@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/platform/storage"
+	"fiber-api-example/app/utils/logger"
+)
+
+// TrashPurger struct for permanently deleting books, which are in the trash
+// longer than the retention period. It runs in background until closed.
+type TrashPurger struct {
+	books     books.BookRepository
+	blobs     storage.BlobStore
+	retention time.Duration
+	interval  time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTrashPurger func for creating a purger of old trashed books,
+// which also deletes their covers and files. A zero retention disables purging.
+func NewTrashPurger(repo books.BookRepository, blobs storage.BlobStore, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		books:     repo,
+		blobs:     blobs,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start method for starting the purger in background.
+func (p *TrashPurger) Start() {
+	if p.retention <= 0 || p.interval <= 0 {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.purge()
+
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close method for stopping the purger and waiting for a running purge.
+func (p *TrashPurger) Close() error {
+	close(p.stop)
+	p.wg.Wait()
+	return nil
+}
+
+// purge deletes books trashed before the retention period.
+func (p *TrashPurger) purge() {
+	purged, err := p.books.PurgeDeletedBooks(time.Now().Add(-p.retention))
+	if err != nil {
+		logger.Error("Can't purge trashed books: ", err)
+		return
+	}
+	if len(purged) > 0 {
+		logger.Info("Purged trashed books: ", len(purged))
+	}
+
+	// Delete covers and files of purged books, leftovers are only logged.
+	for _, id := range purged {
+		if err := storage.DeleteBookBlobs(context.Background(), p.blobs, id); err != nil {
+			logger.Error("Can't delete blobs of purged book "+id.String()+": ", err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"fiber-api-example/app/models/outbox"
+	"fiber-api-example/app/models/webhooks"
+	"fiber-api-example/app/utils/logger"
+	"github.com/spf13/viper"
+)
+
+// Headers of webhook deliveries.
+const (
+	// HeaderWebhookID is the ID of the delivery, it is the same on every attempt.
+	HeaderWebhookID = "Webhook-Id"
+
+	// HeaderWebhookEvent is the type of the delivered event.
+	HeaderWebhookEvent = "Webhook-Event"
+
+	// HeaderWebhookSignature is "t=<unix time>,v1=<hex HMAC-SHA256>" signing
+	// "<unix time>.<body>" with the secret of the endpoint.
+	HeaderWebhookSignature = "Webhook-Signature"
+)
+
+// maxResponseBody is the number of response bytes kept for debugging.
+const maxResponseBody = 4096
+
+type WebhookDispatcherConfig struct {
+	// PollInterval is the time between checks for due deliveries, zero disables sending.
+	// BatchSize is the number of deliveries sent per check.
+	PollInterval time.Duration
+	BatchSize    int
+
+	// Timeout is the time to wait for a response of an endpoint.
+	Timeout time.Duration
+
+	// MaxAttempts is the number of attempts, after which a delivery is dead.
+	// The delay before a retry starts with Backoff and doubles up to BackoffMax.
+	MaxAttempts int
+	Backoff     time.Duration
+	BackoffMax  time.Duration
+}
+
+// GetWebhookDispatcherConfig func for reading webhook configuration.
+func GetWebhookDispatcherConfig() WebhookDispatcherConfig {
+	return WebhookDispatcherConfig{
+		PollInterval: viper.GetDuration("WEBHOOK_POLL_INTERVAL"),
+		BatchSize:    viper.GetInt("WEBHOOK_BATCH_SIZE"),
+		Timeout:      viper.GetDuration("WEBHOOK_TIMEOUT"),
+		MaxAttempts:  viper.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+		Backoff:      viper.GetDuration("WEBHOOK_BACKOFF"),
+		BackoffMax:   viper.GetDuration("WEBHOOK_BACKOFF_MAX"),
+	}
+}
+
+// WebhookDispatcher struct for delivering book events to webhook endpoints.
+// Events are stored as deliveries, which are sent in background until closed.
+type WebhookDispatcher struct {
+	webhooks webhooks.WebhookRepository
+	config   WebhookDispatcherConfig
+	client   *http.Client
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWebhookDispatcher func for creating a dispatcher of webhook deliveries.
+func NewWebhookDispatcher(repo webhooks.WebhookRepository, config WebhookDispatcherConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhooks: repo,
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		stop:     make(chan struct{}),
+	}
+}
+
+// Publish method for storing deliveries of an outbox message to the subscribed endpoints,
+// the deliveries are sent later. Deliveries of republished messages are skipped.
+func (d *WebhookDispatcher) Publish(_ context.Context, m outbox.Message) error {
+	return d.webhooks.CreateWebhookDeliveries(m.EventID, m.EventType, m.Payload)
+}
+
+// Start method for starting the dispatcher in background.
+func (d *WebhookDispatcher) Start() {
+	if d.config.PollInterval <= 0 {
+		return
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			d.dispatch()
+
+			select {
+			case <-ticker.C:
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close method for stopping the dispatcher and waiting for running deliveries.
+func (d *WebhookDispatcher) Close() error {
+	close(d.stop)
+	d.wg.Wait()
+	return nil
+}
+
+// dispatch sends all due deliveries. Claimed deliveries are not due again,
+// until the requests to them timed out, so a crash does not lose them.
+func (d *WebhookDispatcher) dispatch() {
+	for {
+		lease := d.config.Timeout * time.Duration(d.config.BatchSize+1)
+		deliveries, err := d.webhooks.ClaimWebhookDeliveries(time.Now(), lease, d.config.BatchSize)
+		if err != nil {
+			logger.Error("Can't claim webhook deliveries: ", err)
+			return
+		}
+
+		for i := range deliveries {
+			d.send(&deliveries[i])
+		}
+
+		// Stop, if there are no more due deliveries or the dispatcher is closed.
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+		if len(deliveries) < d.config.BatchSize {
+			return
+		}
+	}
+}
+
+// send makes an attempt to send the delivery and records its result.
+func (d *WebhookDispatcher) send(delivery *webhooks.Delivery) {
+	endpoint, err := d.webhooks.GetWebhookEndpoint(delivery.EndpointID)
+	if err != nil {
+		logger.Error("Can't get webhook endpoint: ", err)
+		return
+	}
+
+	// Keep deliveries to inactive endpoints, until they are active again.
+	if !endpoint.Active {
+		return
+	}
+
+	// Send payload and measure the response time.
+	start := time.Now()
+	status, body, err := d.post(&endpoint, delivery)
+	attempt := &webhooks.Attempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+		CreatedAt:  start,
+		DurationMS: int(time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		msg := err.Error()
+		attempt.Error = &msg
+	} else {
+		attempt.ResponseStatus = &status
+		attempt.ResponseBody = &body
+	}
+
+	// Set the resulting state of the delivery.
+	now := time.Now()
+	delivery.Attempts = attempt.Attempt
+	delivery.UpdatedAt = now
+	switch {
+	case attempt.Succeeded():
+		delivery.Status = webhooks.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = nil
+	default:
+		msg := "endpoint responded with status " + strconv.Itoa(status)
+		if attempt.Error != nil {
+			msg = *attempt.Error
+		}
+		delivery.LastError = &msg
+
+		if delivery.Attempts >= d.config.MaxAttempts {
+			delivery.Status = webhooks.DeliveryDead
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(backoff(d.config.Backoff, d.config.BackoffMax, delivery.Attempts))
+			delivery.Status = webhooks.DeliveryFailed
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := d.webhooks.RecordWebhookAttempt(delivery, attempt); err != nil {
+		logger.Error("Can't record webhook attempt: ", err)
+	}
+}
+
+// post sends the signed payload of the delivery to the endpoint.
+// It returns the status and the beginning of the response body.
+func (d *WebhookDispatcher) post(endpoint *webhooks.Endpoint, delivery *webhooks.Delivery) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fiber-api-example-webhooks")
+	req.Header.Set(HeaderWebhookID, delivery.ID.String())
+	req.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	req.Header.Set(HeaderWebhookSignature, Sign(endpoint.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(bytes.ToValidUTF8(body, nil)), nil
+}
+
+// Sign func for creating the signature header of a payload sent at the given time.
+// Receivers compute the HMAC-SHA256 of "<t>.<body>" with the secret and compare
+// it to v1, and reject old timestamps to prevent replays.
+func Sign(secret string, at time.Time, payload []byte) string {
+	t := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
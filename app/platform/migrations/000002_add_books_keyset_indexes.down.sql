@@ -0,0 +1,6 @@
+-- Delete keyset pagination indexes
+DROP INDEX IF EXISTS books_rating_id_idx;
+DROP INDEX IF EXISTS books_author_id_idx;
+DROP INDEX IF EXISTS books_title_id_idx;
+DROP INDEX IF EXISTS books_updated_at_id_idx;
+DROP INDEX IF EXISTS books_created_at_id_idx;
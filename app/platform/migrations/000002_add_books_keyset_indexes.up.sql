@@ -0,0 +1,7 @@
+-- Add indexes for keyset pagination of books.
+-- Each index ends with the primary key, which is used as a tie-breaker.
+CREATE INDEX IF NOT EXISTS books_created_at_id_idx ON books (created_at, id);
+CREATE INDEX IF NOT EXISTS books_updated_at_id_idx ON books (updated_at, id);
+CREATE INDEX IF NOT EXISTS books_title_id_idx ON books (title, id);
+CREATE INDEX IF NOT EXISTS books_author_id_idx ON books (author, id);
+CREATE INDEX IF NOT EXISTS books_rating_id_idx ON books (((book_attrs->>'rating')::int), id);
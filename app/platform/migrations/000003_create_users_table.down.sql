@@ -0,0 +1,5 @@
+-- Delete owner of books
+ALTER TABLE books DROP COLUMN IF EXISTS user_id;
+
+-- Delete tables
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,16 @@
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    email VARCHAR (255) NOT NULL,
+    user_status INT NOT NULL,
+    user_role VARCHAR (25) NOT NULL
+);
+
+-- Add indexes
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
+
+-- Add owner of books
+ALTER TABLE books ADD COLUMN user_id UUID REFERENCES users (id) ON DELETE RESTRICT;
+CREATE INDEX books_user_id_idx ON books (user_id);
@@ -0,0 +1,2 @@
+-- Delete password hash of users
+ALTER TABLE users DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,3 @@
+-- Add bcrypt password hash of users.
+-- Users without a password can not log in.
+ALTER TABLE users ADD COLUMN password_hash VARCHAR (255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Delete version of books
+ALTER TABLE books DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+-- Add version of books for optimistic concurrency control.
+-- It is incremented on every update.
+ALTER TABLE books ADD COLUMN version INT NOT NULL DEFAULT 1;
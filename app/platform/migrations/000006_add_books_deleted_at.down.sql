@@ -0,0 +1,4 @@
+-- Delete soft delete of books, trashed books are deleted for good
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS books_deleted_at_idx;
+ALTER TABLE books DROP COLUMN IF EXISTS deleted_at;
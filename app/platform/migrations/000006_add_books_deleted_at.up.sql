@@ -0,0 +1,4 @@
+-- Add soft delete of books.
+-- Deleted books are kept in the trash until they are purged.
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE NULL;
+CREATE INDEX books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Delete book revisions table
+DROP TABLE IF EXISTS book_revisions;
@@ -0,0 +1,12 @@
+-- Create book revisions table.
+-- Every create, update, delete and restore of a book stores a full snapshot,
+-- the revision number is the version of the book after the change.
+CREATE TABLE IF NOT EXISTS book_revisions (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    user_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    action VARCHAR (25) NOT NULL,
+    snapshot JSONB NOT NULL,
+    PRIMARY KEY (book_id, revision)
+);
@@ -0,0 +1,4 @@
+-- Delete lifecycle of book statuses, archived and withdrawn books become drafts
+UPDATE books SET book_status = 0 WHERE book_status > 1;
+ALTER TABLE books DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_book_status_check;
@@ -0,0 +1,6 @@
+-- Add lifecycle of book statuses: 0 == draft, 1 == active, 2 == archived, 3 == withdrawn.
+-- The time of the latest status change is kept with the book,
+-- every change is recorded as a revision.
+ALTER TABLE books ADD CONSTRAINT books_book_status_check CHECK (book_status BETWEEN 0 AND 3);
+ALTER TABLE books ADD COLUMN status_changed_at TIMESTAMP WITH TIME ZONE NULL;
+UPDATE books SET status_changed_at = created_at;
@@ -0,0 +1,2 @@
+-- Delete idempotency keys table
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,18 @@
+-- Create idempotency keys table.
+-- Requests made with an Idempotency-Key header are stored with their response,
+-- which is replayed on retries until the key expires. A zero status means
+-- the request is still in progress.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key VARCHAR (255) NOT NULL,
+    request_hash CHAR (64) NOT NULL,
+    status INT NOT NULL DEFAULT 0,
+    headers JSONB NULL,
+    body BYTEA NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+-- Add indexes
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,47 @@
+-- Create webhook endpoints table.
+-- Events of the listed types, or all events if none are listed,
+-- are delivered to active endpoints and signed with their secret.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    user_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    url VARCHAR (2048) NOT NULL,
+    secret VARCHAR (255) NOT NULL,
+    events JSONB NOT NULL DEFAULT '[]',
+    description VARCHAR (255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Create webhook deliveries table.
+-- Pending and failed deliveries are sent, when next_attempt_at is due.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR (64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR (16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    CONSTRAINT webhook_deliveries_status_check CHECK (status IN ('pending', 'succeeded', 'failed', 'dead'))
+);
+
+-- Create webhook attempts table, kept for debugging deliveries.
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    duration_ms INT NOT NULL,
+    response_status INT NULL,
+    response_body TEXT NULL,
+    error TEXT NULL,
+    PRIMARY KEY (delivery_id, attempt)
+);
+
+-- Add indexes
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status IN ('pending', 'failed');
+CREATE INDEX webhook_deliveries_endpoint_id_idx ON webhook_deliveries (endpoint_id, created_at);
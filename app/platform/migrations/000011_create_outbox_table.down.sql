@@ -0,0 +1,3 @@
+-- Delete outbox table
+DROP INDEX IF EXISTS webhook_deliveries_endpoint_event_idx;
+DROP TABLE IF EXISTS outbox;
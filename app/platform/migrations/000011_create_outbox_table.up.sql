@@ -0,0 +1,25 @@
+-- Create outbox table.
+-- Domain events are added in the transaction of the change they describe
+-- and published by the relay in order of their ID per aggregate.
+-- Messages are kept until published, failed attempts are retried with backoff.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    event_type VARCHAR (64) NOT NULL,
+    aggregate_type VARCHAR (64) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    last_error TEXT NULL,
+    published_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- Add indexes
+CREATE INDEX outbox_unpublished_idx ON outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_due_idx ON outbox (next_attempt_at, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+
+-- Events are published at least once, deliveries of republished events are skipped.
+CREATE UNIQUE INDEX webhook_deliveries_endpoint_event_idx ON webhook_deliveries (endpoint_id, event_id);
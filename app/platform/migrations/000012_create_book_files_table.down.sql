@@ -0,0 +1,2 @@
+-- Delete book files table
+DROP TABLE IF EXISTS book_files;
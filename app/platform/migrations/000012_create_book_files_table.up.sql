@@ -0,0 +1,17 @@
+-- Create book files table.
+-- E-book files of books are kept in the blob store, the table keeps
+-- their size and SHA-256 checksum. Files are deleted with their book.
+CREATE TABLE IF NOT EXISTS book_files (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+    user_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+    name VARCHAR (255) NOT NULL,
+    format VARCHAR (16) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR (64) NOT NULL,
+    CONSTRAINT book_files_format_check CHECK (format IN ('pdf', 'epub'))
+);
+
+-- Add indexes
+CREATE INDEX book_files_book_id_idx ON book_files (book_id, created_at);
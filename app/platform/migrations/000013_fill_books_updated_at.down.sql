@@ -0,0 +1,3 @@
+-- Allow books without updated_at again
+ALTER TABLE books ALTER COLUMN updated_at DROP NOT NULL;
+ALTER TABLE books ALTER COLUMN updated_at DROP DEFAULT;
@@ -0,0 +1,5 @@
+-- Fill updated_at of books created before it was set on insert.
+-- Every book has it from now on.
+UPDATE books SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE books ALTER COLUMN updated_at SET DEFAULT now();
+ALTER TABLE books ALTER COLUMN updated_at SET NOT NULL;
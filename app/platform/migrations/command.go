@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Usage describes the arguments of the migrate command.
+const Usage = "usage: migrate up|down|status|goto N"
+
+// RunCommand func for executing a migrate command with the given arguments.
+func RunCommand(db *sqlx.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(Usage)
+	}
+	if db == nil {
+		return errors.New("migrations require the postgres driver")
+	}
+
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up()
+	case "down":
+		return m.Down()
+	case "goto":
+		if len(args) != 2 {
+			return errors.New(Usage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return m.Goto(version)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		return printStatus(out, statuses)
+	default:
+		return errors.New(Usage)
+	}
+}
+
+// printStatus writes the migration statuses as a table.
+func printStatus(out io.Writer, statuses []Status) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,325 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fiber-api-example/app/utils/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// files holds the SQL migrations shipped with the binary.
+//
+//go:embed *.sql
+var files embed.FS
+
+// lockKey is the key of the PostgreSQL advisory lock held while migrating.
+const lockKey = 7235612093
+
+// Migration struct to describe one versioned migration.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status struct to describe the state of one migration.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator struct for applying migrations to a database.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// NewMigrator func for creating a migrator with the embedded migrations.
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up method for applying all pending migrations.
+func (m *Migrator) Up() error {
+	return m.Goto(m.latest())
+}
+
+// Down method for rolling back the latest applied migration.
+func (m *Migrator) Down() error {
+	return m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.apply(conn, m.migrations[i], false)
+			}
+		}
+
+		logger.Info("No migrations to roll back")
+		return nil
+	})
+}
+
+// Goto method for migrating up or down to the given version.
+// Version 0 rolls back all migrations.
+func (m *Migrator) Goto(version int64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.adoptBaseline(conn, applied); err != nil {
+			return err
+		}
+
+		// Roll back applied migrations above the target version, newest first.
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.apply(conn, mig, false); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Apply pending migrations up to the target version, oldest first.
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.apply(conn, mig, true); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Status method for getting the state of all known migrations.
+func (m *Migrator) Status() ([]Status, error) {
+	statuses := []Status{}
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			status := Status{Version: mig.Version, Name: mig.Name}
+			if t, ok := applied[mig.Version]; ok {
+				status.AppliedAt = &t
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory
+// lock, so that concurrently starting instances migrate one after another.
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Migrations may change session settings, like the timezone set by the
+	// initial one, reset them before the connection goes back to the pool.
+	defer conn.ExecContext(ctx, `RESET ALL`)
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	// Create migration history table.
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR (255) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+	)`); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// applied returns the applied migration versions with their apply time.
+func (m *Migrator) applied(conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// adoptBaseline records the initial migration as applied, if its tables were
+// created before the migration history was kept, so it is not run again.
+func (m *Migrator) adoptBaseline(conn *sql.Conn, applied map[int64]time.Time) error {
+	if len(applied) > 0 || len(m.migrations) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	exists := false
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('books') IS NOT NULL`).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	baseline := m.migrations[0]
+	if _, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, baseline.Version, baseline.Name); err != nil {
+		return err
+	}
+	applied[baseline.Version] = time.Now()
+	logger.Info(fmt.Sprintf("Recorded existing schema as migration %d_%s", baseline.Version, baseline.Name))
+
+	return nil
+}
+
+// apply runs one migration in a transaction together with its history record.
+func (m *Migrator) apply(conn *sql.Conn, mig Migration, up bool) error {
+	ctx := context.Background()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, record, args := mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, []interface{}{mig.Version}
+	if up {
+		script, record, args = mig.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, []interface{}{mig.Version, mig.Name}
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	direction := "Applied"
+	if !up {
+		direction = "Rolled back"
+	}
+	logger.Info(fmt.Sprintf("%s migration %d_%s", direction, mig.Version, mig.Name))
+
+	return nil
+}
+
+// latest returns the newest known migration version.
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// find returns the migration with the given version or nil.
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// load reads migrations named like "000001_name.up.sql" and "000001_name.down.sql".
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, name := range names {
+		base := strings.TrimSuffix(name, ".sql")
+
+		up := strings.HasSuffix(base, ".up")
+		if !up && !strings.HasSuffix(base, ".down") {
+			return nil, fmt.Errorf("migration %s must end with .up.sql or .down.sql", name)
+		}
+		base = strings.TrimSuffix(strings.TrimSuffix(base, ".up"), ".down")
+
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 || version <= 0 {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>", name)
+		}
+
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = mig
+		}
+		if up {
+			mig.Up = string(script)
+		} else {
+			mig.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
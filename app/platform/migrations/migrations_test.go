@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name:  "empty",
+			files: fstest.MapFS{},
+			want:  []Migration{},
+		},
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"000010_add_index.up.sql":      {Data: []byte("CREATE INDEX i;")},
+				"000010_add_index.down.sql":    {Data: []byte("DROP INDEX i;")},
+				"000002_create_table.up.sql":   {Data: []byte("CREATE TABLE t;")},
+				"000002_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+			},
+			want: []Migration{
+				{Version: 2, Name: "create_table", Up: "CREATE TABLE t;", Down: "DROP TABLE t;"},
+				{Version: 10, Name: "add_index", Up: "CREATE INDEX i;", Down: "DROP INDEX i;"},
+			},
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"000001_create_table.up.sql": {Data: []byte("CREATE TABLE t;")},
+			},
+			want: []Migration{{Version: 1, Name: "create_table", Up: "CREATE TABLE t;"}},
+		},
+		{
+			name: "other files",
+			files: fstest.MapFS{
+				"README.md":                  {Data: []byte("# Migrations")},
+				"000001_create_table.up.sql": {Data: []byte("CREATE TABLE t;")},
+			},
+			want: []Migration{{Version: 1, Name: "create_table", Up: "CREATE TABLE t;"}},
+		},
+		{
+			name:    "no direction",
+			files:   fstest.MapFS{"000001_create_table.sql": {}},
+			wantErr: "must end with .up.sql or .down.sql",
+		},
+		{
+			name:    "no version",
+			files:   fstest.MapFS{"create_table.up.sql": {}},
+			wantErr: "must be named <version>_<name>",
+		},
+		{
+			name:    "no name",
+			files:   fstest.MapFS{"000001.up.sql": {}},
+			wantErr: "must be named <version>_<name>",
+		},
+		{
+			name:    "zero version",
+			files:   fstest.MapFS{"000000_init.up.sql": {}},
+			wantErr: "must be named <version>_<name>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	// Versions are numbered without gaps and every migration can be reverted.
+	for i, mig := range migrations {
+		if mig.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", mig.Name, mig.Version, i+1)
+		}
+		if strings.TrimSpace(mig.Up) == "" || strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("migration %d_%s has no up or down script", mig.Version, mig.Name)
+		}
+	}
+}
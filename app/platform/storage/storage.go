@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Key prefixes of the blobs stored for a book, which are stored
+// below "<prefix>/<book id>/".
+const (
+	CoversPrefix = "covers"
+	FilesPrefix  = "files"
+)
+
+// BlobStore interface to describe a storage of binary objects by key,
+// e.g. uploaded files. Keys are slash-separated paths like "covers/<id>/original".
+// Opening a missing key returns an error matching models.ErrNotFound.
+type BlobStore interface {
+	// Put stores the content read from r under the key, replacing a stored one.
+	// Readers of the key see either the old or the new content.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Open opens the content stored under the key, the caller must close it.
+	Open(ctx context.Context, key string) (*Blob, error)
+
+	// Delete removes the content stored under the key, missing keys are ignored.
+	Delete(ctx context.Context, key string) error
+
+	// DeletePrefix removes the contents stored under all keys below the prefix,
+	// i.e. starting with "<prefix>/".
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Blob struct to describe stored content opened for reading.
+type Blob struct {
+	io.ReadSeekCloser
+
+	// Size is the length of the content in bytes.
+	Size int64
+
+	// ModTime is the time the content was stored.
+	ModTime time.Time
+}
+
+type Config struct {
+	// Driver is "local", the only driver for now.
+	Driver string
+
+	// LocalRoot is the directory of the local driver.
+	LocalRoot string
+}
+
+// GetConfig func for reading blob storage configuration.
+func GetConfig() Config {
+	return Config{
+		Driver:    viper.GetString("STORAGE_DRIVER"),
+		LocalRoot: viper.GetString("STORAGE_LOCAL_ROOT"),
+	}
+}
+
+// New func for creating the blob store of the configuration.
+func New(config Config) (BlobStore, error) {
+	switch config.Driver {
+	case "local":
+		return NewLocal(config.LocalRoot)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.Driver)
+	}
+}
+
+// DeleteBookBlobs func for removing all blobs stored for the book
+// by given ID, its covers and files.
+func DeleteBookBlobs(ctx context.Context, blobs BlobStore, id uuid.UUID) error {
+	for _, prefix := range []string{CoversPrefix, FilesPrefix} {
+		if err := blobs.DeletePrefix(ctx, prefix+"/"+id.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"fiber-api-example/app/models/books"
+	"fiber-api-example/app/models/users"
+	"fiber-api-example/app/utils/auth"
+)
+
+// CanModifyBook func for checking, if the principal may update or delete
+// the book. Only the owner of the book and admins may change it.
+func CanModifyBook(p auth.Principal, b *books.Book) bool {
+	return isAdmin(p) || b.UserID == p.UserID
+}
+
+// CanAccessUser func for checking, if the principal may read or update
+// the user. Users may only access themselves, admins may access everyone.
+func CanAccessUser(p auth.Principal, u *users.User) bool {
+	return isAdmin(p) || u.ID == p.UserID
+}
+
+// CanManageUsers func for checking, if the principal may list all users
+// and change their roles or statuses.
+func CanManageUsers(p auth.Principal) bool {
+	return isAdmin(p)
+}
+
+// CanListAllDeletedBooks func for checking, if the principal may list
+// the whole trash. Other users may only list their own deleted books.
+func CanListAllDeletedBooks(p auth.Principal) bool {
+	return isAdmin(p)
+}
+
+// CanManageWebhooks func for checking, if the principal may manage webhook
+// endpoints and read their deliveries. Deliveries carry all book changes.
+func CanManageWebhooks(p auth.Principal) bool {
+	return isAdmin(p)
+}
+
+// CanDownloadBookFiles func for checking, if the principal may list and
+// download the e-book files of the book. Files of active and archived books
+// are public, files of drafts and withdrawn books only for who may change them.
+func CanDownloadBookFiles(p auth.Principal, b *books.Book) bool {
+	return isPublic(b) || CanModifyBook(p, b)
+}
+
+// CanWatchBook func for checking, if the principal may receive changes of the
+// book from streams. Like files, changes of active and archived books are public,
+// changes of drafts, withdrawn and purged books only for who may change them.
+func CanWatchBook(p auth.Principal, b *books.Book) bool {
+	return isPublic(b) || CanModifyBook(p, b)
+}
+
+func isPublic(b *books.Book) bool {
+	return b.BookStatus == books.StatusActive || b.BookStatus == books.StatusArchived
+}
+
+func isAdmin(p auth.Principal) bool {
+	return p.Role == users.RoleAdmin
+}
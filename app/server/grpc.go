@@ -0,0 +1,97 @@
+package server
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// GRPCServer struct for serving gRPC services on their own port next to the Fiber app,
+// with server reflection and standard health checking. Services are registered on it
+// before Start. It is passed to StartServerWithGracefulShutdown as closer: draining
+// reports all services as not serving, closing waits for running calls.
+type GRPCServer struct {
+	*grpc.Server
+	health *health.Server
+	done   chan struct{}
+}
+
+// CreateGRPC func for creating the gRPC server with the given options, e.g. interceptors.
+func CreateGRPC(opts ...grpc.ServerOption) *GRPCServer {
+	s := &GRPCServer{
+		Server: grpc.NewServer(opts...),
+		health: health.NewServer(),
+	}
+
+	healthpb.RegisterHealthServer(s.Server, s.health)
+	reflection.Register(s.Server)
+
+	return s
+}
+
+// Start method for serving the registered services in background on the
+// address given by GRPC_ADDR, an empty address disables the server.
+func (s *GRPCServer) Start() error {
+	addr := viper.GetString("GRPC_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// Report the server and every registered service as serving.
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	for name := range s.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		if err := s.Serve(listener); err != nil {
+			log.Printf("Oops... gRPC server is not running! Reason: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Drain method for reporting all services as not serving,
+// so clients stop sending new calls before the server shuts down.
+func (s *GRPCServer) Drain() {
+	s.health.Shutdown()
+}
+
+// Close method for stopping the server after running calls are done.
+// Calls still running after GRPC_SHUTDOWN_TIMEOUT, e.g. health watches,
+// are cancelled.
+func (s *GRPCServer) Close() error {
+	if s.done == nil {
+		return nil
+	}
+
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(viper.GetDuration("GRPC_SHUTDOWN_TIMEOUT")):
+		s.Stop()
+	}
+	<-s.done
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimitConfig struct to describe the max size of request bodies
+// and the requests skipping the limit.
+type BodyLimitConfig struct {
+	// Next defines a function to skip the middleware, when it returns true.
+	Next func(c *fiber.Ctx) bool
+
+	// Limit is the max size of request bodies in bytes.
+	Limit int
+}
+
+// BodyLimit rejects requests with a body larger than the limit with 413.
+// The server streams request bodies, see config.GetFiberConfig, so the
+// body is read here up to the limit and handlers get it by ctx.Body().
+func BodyLimit(config *BodyLimitConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if config.Next != nil && config.Next(ctx) {
+			return ctx.Next()
+		}
+
+		size := ctx.Request().Header.ContentLength()
+		if size > config.Limit {
+			return tooLarge(config.Limit)
+		}
+
+		// Read the streamed body, chunked bodies have no length.
+		stream := ctx.Context().RequestBodyStream()
+		if stream == nil || size == 0 {
+			return ctx.Next()
+		}
+		body, err := io.ReadAll(io.LimitReader(stream, int64(config.Limit)+1))
+		if err != nil {
+			return problem.Wrap(fiber.StatusBadRequest, err, "request body can't be read")
+		}
+		if len(body) > config.Limit {
+			return tooLarge(config.Limit)
+		}
+		ctx.Request().SetBody(body)
+
+		return ctx.Next()
+	}
+}
+
+// StreamedBodyLimit rejects uploads larger than the limit with 413. Their body
+// is not read, the handler reads it as stream, e.g. by ctx.FormFile, which
+// keeps large files on disk. Uploads must have a Content-Length, the stream
+// ends after it. The route must be registered by StreamBody to skip BodyLimit.
+func StreamedBodyLimit(limit int) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		size := ctx.Request().Header.ContentLength()
+		if size < 0 {
+			return problem.New(fiber.StatusLengthRequired, "request body must have a Content-Length")
+		}
+		if size > limit {
+			return tooLarge(limit)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// streamedRoutes are the routes reading their body as stream, see StreamBody.
+var streamedRoutes = struct {
+	sync.RWMutex
+	paths map[string][][]string
+}{paths: map[string][][]string{}}
+
+// StreamBody func for registering a route, which reads its body as stream
+// limited by StreamedBodyLimit. The path is matched against the end of
+// request paths, so it is found with any prefix, e.g. the API version.
+func StreamBody(method, path string) {
+	streamedRoutes.Lock()
+	defer streamedRoutes.Unlock()
+
+	streamedRoutes.paths[method] = append(streamedRoutes.paths[method], segments(path))
+}
+
+// IsStreamed func for checking, if the request is made to a route
+// registered by StreamBody, e.g. an upload.
+func IsStreamed(ctx *fiber.Ctx) bool {
+	streamedRoutes.RLock()
+	defer streamedRoutes.RUnlock()
+
+	path := segments(ctx.Path())
+	for _, route := range streamedRoutes.paths[ctx.Method()] {
+		if matchSegments(route, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// segments func for splitting a path into its lower-case segments.
+func segments(path string) []string {
+	return strings.Split(strings.Trim(strings.ToLower(path), "/"), "/")
+}
+
+// matchSegments func for checking, if the path ends with the segments
+// of the route, parameters like ":id" match any segment.
+func matchSegments(route, path []string) bool {
+	if len(path) < len(route) {
+		return false
+	}
+	path = path[len(path)-len(route):]
+	for i, segment := range route {
+		if path[i] == "" || (!strings.HasPrefix(segment, ":") && segment != path[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tooLarge returns the problem of bodies larger than the limit.
+func tooLarge(limit int) error {
+	return problem.New(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("request body must not be larger than %d bytes", limit))
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"sort"
+	"strings"
+	"time"
+
+	"fiber-api-example/app/models/idempotency"
+	"fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// Headers of idempotent requests.
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength is the size of the key column.
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyConfig struct {
+	// Store keeps requests and their responses.
+	Store idempotency.IdempotencyRepository
+
+	// TTL is the time responses are replayed to retries, zero disables the middleware.
+	TTL time.Duration
+}
+
+// Idempotency stores the response of requests made with an Idempotency-Key header
+// and replays it to retries of the caller with the same key. Retries with another
+// payload are rejected with 422, retries of a request in progress with 409.
+// Server errors are not stored, so the request can be retried.
+// It must be applied after Protected, requests are keyed by the caller.
+func Idempotency(config *IdempotencyConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(HeaderIdempotencyKey)
+		principal, ok := Principal(ctx)
+		if key == "" || !ok || config.TTL <= 0 {
+			return ctx.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return problem.New(fiber.StatusBadRequest, "Idempotency-Key must not be longer than 255 characters")
+		}
+
+		// Reserve key for the request.
+		digest, err := requestHash(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		record := &idempotency.Record{
+			UserID:      principal.UserID,
+			Key:         key,
+			RequestHash: digest,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(config.TTL),
+		}
+		existing, err := config.Store.ReserveIdempotencyKey(record)
+		if err != nil {
+			return err
+		}
+
+		// Replay the stored response of a retry.
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				return problem.New(fiber.StatusUnprocessableEntity, "Idempotency-Key was used for another request")
+			case !existing.Completed():
+				return problem.New(fiber.StatusConflict, "request with this Idempotency-Key is still in progress")
+			}
+			return replay(ctx, existing)
+		}
+
+		// Release key, if the response is not stored, e.g. on panics.
+		stored := false
+		defer func() {
+			if !stored {
+				if err := config.Store.ReleaseIdempotencyKey(record.UserID, record.Key); err != nil {
+					logger.Error("Can't release idempotency key: ", err)
+				}
+			}
+		}()
+
+		// Handle request, errors are written here to store them as well.
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				return err
+			}
+		}
+		if ctx.Response().StatusCode() >= fiber.StatusInternalServerError {
+			return nil
+		}
+
+		// Store response.
+		record.Status = ctx.Response().StatusCode()
+		record.Headers = responseHeaders(ctx)
+		record.Body = ctx.Response().Body()
+		if err := config.Store.CompleteIdempotencyKey(record); err != nil {
+			logger.Error("Can't store idempotent response: ", err)
+			return nil
+		}
+		stored = true
+
+		return nil
+	}
+}
+
+// requestHash returns the hash of the method, path and body of the request.
+// Multipart forms are hashed by their values and files, so streamed uploads
+// are read from the parsed form instead of into memory.
+func requestHash(ctx *fiber.Ctx) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(ctx.Method() + " " + ctx.Path() + "\n"))
+
+	if !isMultipart(ctx) {
+		h.Write(ctx.Body())
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return "", problem.Wrap(fiber.StatusBadRequest, err, "multipart form can't be read")
+	}
+	for _, name := range sortedKeys(form.Value) {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(h, "%s=%q\n", name, value)
+		}
+	}
+	for _, name := range sortedKeys(form.File) {
+		for _, header := range form.File[name] {
+			fmt.Fprintf(h, "%s=%q %d\n", name, header.Filename, header.Size)
+			if err := hashFile(h, header); err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile writes the content of an uploaded file to the hash.
+func hashFile(h hash.Hash, header *multipart.FileHeader) error {
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(h, file)
+	return err
+}
+
+// sortedKeys returns the keys of a form in order.
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isMultipart func for checking, if the request body is a multipart form.
+func isMultipart(ctx *fiber.Ctx) bool {
+	return strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm)
+}
+
+// responseHeaders returns the headers of the response to store,
+// headers describing the connection or the single request are skipped.
+func responseHeaders(ctx *fiber.Ctx) idempotency.Headers {
+	skipped := map[string]bool{
+		"date":           true,
+		"server":         true,
+		"connection":     true,
+		"content-length": true,
+		"set-cookie":     true,
+		strings.ToLower(viper.GetString("MW_FIBER_REQUESTID_HEADER")): true,
+	}
+
+	headers := idempotency.Headers{}
+	ctx.Response().Header.VisitAll(func(key, value []byte) {
+		if name := string(key); !skipped[strings.ToLower(name)] {
+			headers[name] = string(value)
+		}
+	})
+	return headers
+}
+
+// replay writes a stored response.
+func replay(ctx *fiber.Ctx, record *idempotency.Record) error {
+	for name, value := range record.Headers {
+		ctx.Set(name, value)
+	}
+	ctx.Set(HeaderIdempotentReplayed, "true")
+	return ctx.Status(record.Status).Send(record.Body)
+}
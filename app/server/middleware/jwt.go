@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"fiber-api-example/app/utils/problem"
+	"strings"
+
+	"fiber-api-example/app/utils/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+type JWTConfig struct {
+	// Authenticator validates the bearer tokens.
+	Authenticator *auth.Authenticator
+
+	// ContextKey is the key of ctx.Locals to store the token claims under.
+	ContextKey string
+}
+
+// JWT validates the bearer access token of the `Authorization` header
+// and puts its claims with the current role of the user into ctx.Locals
+func JWT(config *JWTConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		header := ctx.Get(fiber.HeaderAuthorization)
+		if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+			return unauthorized(ctx, "missing or malformed JWT")
+		}
+
+		claims, err := config.Authenticator.Authenticate(strings.TrimSpace(header[7:]))
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return unauthorized(ctx, err.Error())
+		}
+		if err != nil {
+			// Return error of the user lookup, the error handler maps it to its status.
+			return err
+		}
+
+		ctx.Locals(config.ContextKey, claims)
+		return ctx.Next()
+	}
+}
+
+// Protected returns the JWT middleware validating tokens with the given
+// authenticator, to apply to routes requiring authentication.
+func Protected(authenticator *auth.Authenticator) fiber.Handler {
+	return JWT(&JWTConfig{
+		Authenticator: authenticator,
+		ContextKey:    viper.GetString("MW_JWT_CONTEXTKEY"),
+	})
+}
+
+// Authenticated returns the JWT middleware for public routes, which
+// authenticate callers sending an `Authorization` header.
+func Authenticated(authenticator *auth.Authenticator) fiber.Handler {
+	handler := Protected(authenticator)
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Get(fiber.HeaderAuthorization) == "" {
+			return ctx.Next()
+		}
+		return handler(ctx)
+	}
+}
+
+// Claims returns the claims stored by the JWT middleware or nil,
+// if the request is not authenticated.
+func Claims(ctx *fiber.Ctx) *auth.Claims {
+	claims, _ := ctx.Locals(viper.GetString("MW_JWT_CONTEXTKEY")).(*auth.Claims)
+	return claims
+}
+
+// Principal returns the authenticated caller. The second value is false,
+// if the request is not authenticated.
+func Principal(ctx *fiber.Ctx) (auth.Principal, bool) {
+	claims := Claims(ctx)
+	if claims == nil {
+		return auth.Principal{}, false
+	}
+	principal, err := claims.Principal()
+	return principal, err == nil
+}
+
+func unauthorized(ctx *fiber.Ctx, msg string) error {
+	ctx.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+	return problem.New(fiber.StatusUnauthorized, msg)
+}
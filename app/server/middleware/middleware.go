@@ -3,6 +3,7 @@ package middleware
 import (
 	"fiber-api-example/app/server/middleware/fiberprometheus"
 	l "fiber-api-example/app/utils/logger"
+	"fiber-api-example/app/utils/problem"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cache"
 	"github.com/gofiber/fiber/v2/middleware/compress"
@@ -25,7 +26,19 @@ func RegisterMiddlewares(app *fiber.App) {
 
 	// Middleware - Recover
 	if viper.GetBool("MW_FIBER_RECOVER_ENABLED") {
-		app.Use(recover.New())
+		app.Use(recover.New(recover.Config{
+			EnableStackTrace:  true,
+			StackTraceHandler: problem.StackTraceHandler,
+		}))
+	}
+
+	// Middleware - RequestID, first to be included in every error
+	if viper.GetBool("MW_FIBER_REQUESTID_ENABLED") {
+		app.Use(requestid.New(requestid.Config{
+			Header: viper.GetString("MW_FIBER_REQUESTID_HEADER"),
+			// TODO: Generator
+			ContextKey: viper.GetString("MW_FIBER_REQUESTID_CONTEXTKEY"),
+		}))
 	}
 
 	// Middleware - Custom Access Logger based on zap
@@ -68,11 +81,22 @@ func RegisterMiddlewares(app *fiber.App) {
 
 	// Middleware - Recover
 	if viper.GetBool("MW_FIBER_RECOVER_ENABLED") {
-		app.Use(recover.New())
+		app.Use(recover.New(recover.Config{
+			EnableStackTrace:  true,
+			StackTraceHandler: problem.StackTraceHandler,
+		}))
 	}
 
+	// Middleware - Body limit, streamed uploads are limited by their routes
+	app.Use(BodyLimit(&BodyLimitConfig{
+		Next:  IsStreamed,
+		Limit: viper.GetInt("FIBER_BODYLIMIT"),
+	}))
+
 	// TODO: Middleware - Basic Authentication
 
+	// Middleware - JWT, applied per route with Protected and Authenticated
+
 	// Middleware - Cache
 	if viper.GetBool("MW_FIBER_CACHE_ENABLED") {
 		app.Use(cache.New(cache.Config{
@@ -111,6 +135,9 @@ func RegisterMiddlewares(app *fiber.App) {
 			},
 			CookieExpires: viper.GetDuration("MW_FIBER_CSRF_COOKIE_EXPIRES"),
 			ContextKey:    viper.GetString("MW_FIBER_CSRF_CONTEXTKEY"),
+			ErrorHandler: func(c *fiber.Ctx, err error) error {
+				return problem.New(fiber.StatusForbidden, err.Error())
+			},
 		}))
 	}
 
@@ -142,7 +169,9 @@ func RegisterMiddlewares(app *fiber.App) {
 			Max:        viper.GetInt("MW_FIBER_LIMITER_MAX"),
 			Expiration: viper.GetDuration("MW_FIBER_LIMITER_EXPIRATION"),
 			// TODO: Key
-			// TODO: LimitReached
+			LimitReached: func(c *fiber.Ctx) error {
+				return problem.New(fiber.StatusTooManyRequests, "rate limit exceeded, retry later")
+			},
 		}))
 	}
 
@@ -158,15 +187,6 @@ func RegisterMiddlewares(app *fiber.App) {
 
 	// TODO: Middleware - Proxy
 
-	// Middleware - RequestID
-	if viper.GetBool("MW_FIBER_REQUESTID_ENABLED") {
-		app.Use(requestid.New(requestid.Config{
-			Header: viper.GetString("MW_FIBER_REQUESTID_HEADER"),
-			// TODO: Generator
-			ContextKey: viper.GetString("MW_FIBER_REQUESTID_CONTEXTKEY"),
-		}))
-	}
-
 	// TODO: Middleware - Timeout
 
 	// Middleware - Logger
@@ -176,7 +196,7 @@ func RegisterMiddlewares(app *fiber.App) {
 			TimeFormat:   viper.GetString("MW_FIBER_LOGGER_TIMEFORMAT"),
 			TimeInterval: viper.GetDuration("MW_FIBER_LOGGER_TIMEINTERVAL"),
 			TimeZone:     viper.GetString("MW_FIBER_LOGGER_TIMEZONE"),
-			Output:       &l.ZapWriter{l.GetLogger()},
+			Output:       &l.ZapWriter{Logger: l.GetLogger()},
 			// TODO: Output
 		}))
 	}
@@ -3,9 +3,11 @@ package server
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/viper"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 
 	"fiber-api-example/app/config"
 )
@@ -24,22 +26,42 @@ func Create() *fiber.App {
 	return app
 }
 
+// Drainer interface to describe resources holding connections open, e.g. streams.
+// The server waits for all connections to become idle, so they are drained first.
+type Drainer interface {
+	Drain()
+}
+
 // StartServerWithGracefulShutdown function for starting server with a graceful shutdown.
-func StartServerWithGracefulShutdown(a *fiber.App) {
+// The given closers (e.g. the database pool) are closed after the server has stopped,
+// closers implementing Drainer are drained before.
+func StartServerWithGracefulShutdown(a *fiber.App, closers ...io.Closer) {
 	// Create channel for idle connections.
 	idleConnsClosed := make(chan struct{})
 
 	go func() {
 		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt) // Catch OS signals.
-		<-sigint                            // wait for OS signal
+		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM) // Catch OS signals.
+		<-sigint                                             // wait for OS signal
 
-		// Received an interrupt signal, shutdown.
+		// Received an interrupt signal, end streams and shutdown.
+		for _, c := range closers {
+			if d, ok := c.(Drainer); ok {
+				d.Drain()
+			}
+		}
 		if err := a.Shutdown(); err != nil {
 			// Error from closing listeners, or context timeout:
 			log.Printf("Oops... Server is not shutting down! Reason: %v", err)
 		}
 
+		// Release shared resources after in-flight requests are done.
+		for _, c := range closers {
+			if err := c.Close(); err != nil {
+				log.Printf("Oops... Resource is not closing! Reason: %v", err)
+			}
+		}
+
 		close(idleConnsClosed)
 	}()
 
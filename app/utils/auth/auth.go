@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Token types.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// ErrInvalidToken is returned for malformed, expired or foreign tokens.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Config struct to describe JWT signing and validation settings.
+type Config struct {
+	// Algorithm is used to sign issued tokens, either HS256 or RS256.
+	Algorithm string
+
+	// Secret is the HS256 key. Tokens signed with HS256 are only accepted,
+	// if it is set.
+	Secret string
+
+	// PublicKey and PrivateKey are PEM encoded RS256 keys, or paths to
+	// files containing them. Tokens signed with RS256 are only accepted,
+	// if the public key is set. The private key is only needed to issue tokens.
+	PublicKey  string
+	PrivateKey string
+
+	// Issuer and Audience are written into issued tokens and
+	// required in validated ones, if set.
+	Issuer   string
+	Audience string
+
+	// Leeway is the allowed clock skew when checking time based claims.
+	Leeway time.Duration
+
+	// AccessTTL and RefreshTTL are the lifetimes of issued tokens.
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Claims struct to describe the claims of access and refresh tokens.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role      string `json:"role,omitempty"`
+	TokenType string `json:"typ"`
+}
+
+// UserID method for getting the ID of the token subject.
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// Principal struct to describe the authenticated caller.
+type Principal struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+// Principal method for getting the caller described by the claims.
+func (c *Claims) Principal() (Principal, error) {
+	id, err := c.UserID()
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	return Principal{UserID: id, Role: c.Role}, nil
+}
+
+// TokenPair struct to describe tokens issued on login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// UserLookup func to describe reading the current role of a token subject.
+// It returns ErrInvalidToken, if the user was deleted or blocked.
+type UserLookup func(id uuid.UUID) (role string, err error)
+
+// Authenticator struct for issuing and validating JWT.
+type Authenticator struct {
+	config     Config
+	method     jwt.SigningMethod
+	publicKey  *rsa.PublicKey
+	privateKey *rsa.PrivateKey
+	lookup     UserLookup
+}
+
+// GetConfig func for reading JWT configuration.
+func GetConfig() Config {
+	return Config{
+		Algorithm:  viper.GetString("JWT_ALGORITHM"),
+		Secret:     viper.GetString("JWT_SECRET"),
+		PublicKey:  viper.GetString("JWT_PUBLIC_KEY"),
+		PrivateKey: viper.GetString("JWT_PRIVATE_KEY"),
+		Issuer:     viper.GetString("JWT_ISSUER"),
+		Audience:   viper.GetString("JWT_AUDIENCE"),
+		Leeway:     viper.GetDuration("JWT_LEEWAY"),
+		AccessTTL:  viper.GetDuration("JWT_ACCESS_TOKEN_TTL"),
+		RefreshTTL: viper.GetDuration("JWT_REFRESH_TOKEN_TTL"),
+	}
+}
+
+// New func for creating an authenticator with the given configuration.
+func New(config Config) (*Authenticator, error) {
+	a := &Authenticator{config: config}
+
+	if config.PublicKey != "" {
+		pem, err := readPEM(config.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if a.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("invalid JWT public key: %w", err)
+		}
+	}
+	if config.PrivateKey != "" {
+		pem, err := readPEM(config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if a.privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("invalid JWT private key: %w", err)
+		}
+		if a.publicKey == nil {
+			a.publicKey = &a.privateKey.PublicKey
+		}
+	}
+
+	switch strings.ToUpper(config.Algorithm) {
+	case "HS256":
+		if config.Secret == "" {
+			return nil, errors.New("JWT_SECRET is required for HS256")
+		}
+		a.method = jwt.SigningMethodHS256
+	case "RS256":
+		if a.publicKey == nil {
+			return nil, errors.New("JWT_PUBLIC_KEY or JWT_PRIVATE_KEY is required for RS256")
+		}
+		a.method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", config.Algorithm)
+	}
+
+	return a, nil
+}
+
+// Issue method for issuing an access and a refresh token for the given user.
+func (a *Authenticator) Issue(userID uuid.UUID, role string) (TokenPair, error) {
+	access, err := a.sign(userID, role, TokenTypeAccess, a.config.AccessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh, err := a.sign(userID, role, TokenTypeRefresh, a.config.RefreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.config.AccessTTL.Seconds()),
+	}, nil
+}
+
+// Parse method for validating a token of the given type and reading its claims.
+func (a *Authenticator) Parse(token, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+
+	parsed, err := jwt.ParseWithClaims(token, claims, a.key)
+	if err != nil && !isOnlyTimeError(err) {
+		return nil, ErrInvalidToken
+	}
+	if parsed == nil || claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+
+	// Check time based claims allowing for clock skew.
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-a.config.Leeway), true) ||
+		!claims.VerifyNotBefore(now.Add(a.config.Leeway), false) ||
+		!claims.VerifyIssuedAt(now.Add(a.config.Leeway), false) {
+		return nil, ErrInvalidToken
+	}
+
+	// Check issuer and audience, if configured.
+	if a.config.Issuer != "" && !claims.VerifyIssuer(a.config.Issuer, true) {
+		return nil, ErrInvalidToken
+	}
+	if a.config.Audience != "" && !claims.VerifyAudience(a.config.Audience, true) {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// SetUserLookup method for checking the subjects of access tokens on
+// authentication. Without a lookup, the role of a token is trusted until it expires.
+func (a *Authenticator) SetUserLookup(lookup UserLookup) {
+	a.lookup = lookup
+}
+
+// Authenticate method for validating an access token and reading its claims.
+// The role of the claims is replaced by the current role of the user,
+// if a user lookup is set, so role changes and blocks apply immediately.
+func (a *Authenticator) Authenticate(token string) (*Claims, error) {
+	claims, err := a.Parse(token, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := claims.Principal()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.lookup != nil {
+		role, err := a.lookup(principal.UserID)
+		if err != nil {
+			return nil, err
+		}
+		claims.Role = role
+	}
+
+	return claims, nil
+}
+
+// sign creates a signed token.
+func (a *Authenticator) sign(userID uuid.UUID, role, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			Issuer:    a.config.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Role:      role,
+		TokenType: tokenType,
+	}
+	if a.config.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{a.config.Audience}
+	}
+
+	var key interface{} = []byte(a.config.Secret)
+	if a.method == jwt.SigningMethodRS256 {
+		if a.privateKey == nil {
+			return "", errors.New("JWT_PRIVATE_KEY is required to issue RS256 tokens")
+		}
+		key = a.privateKey
+	}
+
+	return jwt.NewWithClaims(a.method, claims).SignedString(key)
+}
+
+// key returns the validation key for the signing method of a token.
+func (a *Authenticator) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method {
+	case jwt.SigningMethodHS256:
+		if a.config.Secret != "" {
+			return []byte(a.config.Secret), nil
+		}
+	case jwt.SigningMethodRS256:
+		if a.publicKey != nil {
+			return a.publicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+}
+
+// isOnlyTimeError reports whether validation failed only on time based
+// claims, which are checked again with leeway.
+func isOnlyTimeError(err error) bool {
+	var validationErr *jwt.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	timeErrors := uint32(jwt.ValidationErrorExpired | jwt.ValidationErrorNotValidYet | jwt.ValidationErrorIssuedAt)
+	return validationErr.Errors&^timeErrors == 0
+}
+
+// readPEM returns PEM content given inline or as a file path.
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
@@ -0,0 +1,26 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// dummyHash is compared against, when a user is not found,
+// so that failed logins take the same time for unknown emails.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// HashPassword func for hashing a password with bcrypt.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword func for comparing a password with a bcrypt hash.
+// An empty hash never matches, but takes as long as a real comparison.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
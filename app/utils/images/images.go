@@ -0,0 +1,103 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Content types of the supported image formats.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	WebP = "image/webp"
+)
+
+// ErrUnsupported means the data is not a JPEG, PNG or WebP image.
+var ErrUnsupported = errors.New("image must be JPEG, PNG or WebP")
+
+// Sniff func for detecting the format of an image by its magic bytes.
+// It returns the content type or an empty string, if the format is not supported.
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xFF\xD8\xFF")):
+		return JPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1A\n")):
+		return PNG
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return WebP
+	default:
+		return ""
+	}
+}
+
+// Decode func for decoding a JPEG, PNG or WebP image. Images with more than
+// maxPixels pixels are rejected before decoding, zero disables the limit.
+func Decode(data []byte, maxPixels int) (image.Image, string, error) {
+	contentType := Sniff(data)
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch contentType {
+	case JPEG:
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case PNG:
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case WebP:
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	default:
+		return nil, "", ErrUnsupported
+	}
+
+	// Check the dimensions first, decoding allocates memory for every pixel.
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width < 1 || config.Height < 1 {
+		return nil, "", fmt.Errorf("image has no pixels")
+	}
+	if maxPixels > 0 && config.Width*config.Height > maxPixels {
+		return nil, "", fmt.Errorf("image has %dx%d pixels, more than %d", config.Width, config.Height, maxPixels)
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return img, contentType, nil
+}
+
+// Thumbnail func for scaling an image to the given width, keeping its aspect ratio.
+// Images are not enlarged, narrower ones keep their size.
+func Thumbnail(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width >= bounds.Dx() {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Src, nil)
+	return thumbnail
+}
+
+// Encode func for encoding a thumbnail of an image of the given content type.
+// PNG images stay PNG to keep transparency, all others become JPEG,
+// since there is no WebP encoder.
+func Encode(w io.Writer, img image.Image, contentType string) error {
+	if contentType == PNG {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+}
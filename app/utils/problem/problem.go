@@ -0,0 +1,199 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"fiber-api-example/app/models"
+	"fiber-api-example/app/utils/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// MIMEProblemJSON is the media type of problem details (RFC 7807).
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem types.
+const (
+	// TypeDefault means the problem has no semantics beyond its status code.
+	TypeDefault = "about:blank"
+
+	// TypeValidation means fields of the request are not valid,
+	// they are listed in the errors member.
+	TypeValidation = "/problems/validation"
+)
+
+// internalDetail is shown instead of the details of unexpected errors.
+const internalDetail = "an internal error occurred, please report the request ID"
+
+// stackKey is the ctx.Locals key to keep the stack trace of a panic under.
+const stackKey = "problem.stack"
+
+// Problem struct to describe an error as problem details (RFC 7807).
+// It is an error, handlers return it to be written by ErrorHandler.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+
+	// cause is the internal error, which is logged and only
+	// shown to clients in development environments.
+	cause error
+	stack []byte
+}
+
+// Error method to make Problem implement the error interface.
+func (p *Problem) Error() string {
+	if p.cause != nil {
+		return p.cause.Error()
+	}
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// Unwrap method for getting the internal error of the problem.
+func (p *Problem) Unwrap() error {
+	return p.cause
+}
+
+// New func for creating a problem with the given status and detail.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   TypeDefault,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Wrap func for creating a problem caused by an internal error, e.g. of a parser.
+// Clients get the given detail, the error is logged with a stack trace and
+// only shown in development environments.
+func Wrap(status int, err error, detail string) *Problem {
+	p := New(status, detail)
+	p.cause = err
+	p.stack = debug.Stack()
+	return p
+}
+
+// Validation func for creating a problem listing invalid fields.
+func Validation(fields map[string]string) *Problem {
+	return &Problem{
+		Type:   TypeValidation,
+		Title:  "Your request is not valid.",
+		Status: fiber.StatusBadRequest,
+		Errors: fields,
+	}
+}
+
+// From func for converting any error into a problem.
+// Fiber errors keep their status code, storage errors get the status
+// of their kind, other errors become 500.
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return New(fiberErr.Code, fiberErr.Message)
+	}
+
+	for kind, status := range map[error]int{
+		models.ErrNotFound:  fiber.StatusNotFound,
+		models.ErrConflict:  fiber.StatusConflict,
+		models.ErrTransient: fiber.StatusServiceUnavailable,
+	} {
+		if errors.Is(err, kind) {
+			return Wrap(status, err, kind.Error())
+		}
+	}
+
+	return Wrap(fiber.StatusInternalServerError, err, internalDetail)
+}
+
+// ErrorHandler func for writing errors returned by handlers and
+// middlewares as application/problem+json.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	// Copy problem to set the request related members.
+	p := *From(err)
+	p.Instance = c.OriginalURL()
+	p.RequestID = requestID(c)
+
+	// Log internal errors under the request ID, which is the correlation ID
+	// clients report, and hide their details outside of development.
+	if p.cause != nil {
+		if p.RequestID == "" {
+			p.RequestID = uuid.NewString()
+		}
+		if stack, ok := c.Locals(stackKey).([]byte); ok {
+			p.stack = stack
+		}
+		log(&p)
+		if showDetails() {
+			p.Detail = p.cause.Error()
+		}
+	}
+
+	// Ask clients to retry temporary failures.
+	if p.Status == fiber.StatusServiceUnavailable {
+		c.Set(fiber.HeaderRetryAfter, "1")
+	}
+
+	// Return HTTP response
+	if err := c.Status(p.Status).JSON(&p); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, MIMEProblemJSON)
+	return nil
+}
+
+// StackTraceHandler func for keeping the stack trace of a recovered panic,
+// it is logged with the error by ErrorHandler.
+func StackTraceHandler(c *fiber.Ctx, _ interface{}) {
+	c.Locals(stackKey, debug.Stack())
+}
+
+// log writes the internal error of a problem to the server log.
+func log(p *Problem) {
+	fields := []interface{}{
+		"request_id", p.RequestID,
+		"status", p.Status,
+		"instance", p.Instance,
+		"error", p.cause.Error(),
+	}
+
+	if p.Status < fiber.StatusInternalServerError {
+		logger.GetLogger().Infow("request failed", fields...)
+		return
+	}
+	logger.GetLogger().Errorw("request failed", append(fields, "stack", string(p.stack))...)
+}
+
+// showDetails reports whether internal errors are shown to clients,
+// which is only the case in local and development environments.
+func showDetails() bool {
+	switch strings.ToLower(viper.GetString("APP_ENV")) {
+	case "local", "dev", "development":
+		return true
+	}
+	return false
+}
+
+// requestID returns the ID set by the RequestID middleware, if enabled.
+func requestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(viper.GetString("MW_FIBER_REQUESTID_CONTEXTKEY")).(string); ok {
+		return id
+	}
+	return c.GetRespHeader(viper.GetString("MW_FIBER_REQUESTID_HEADER"))
+}
@@ -12,6 +12,10 @@ func NewValidator() *validator.Validate {
 
 	// Custom validation for uuid.UUID fields.
 	_ = validate.RegisterValidation("uuid", func(fl validator.FieldLevel) bool {
+		// Check typed values directly, their String() is not the UUID text.
+		if id, ok := fl.Field().Interface().(uuid.UUID); ok {
+			return id != uuid.Nil
+		}
 		field := fl.Field().String()
 		if _, err := uuid.Parse(field); err != nil {
 			return false  // if there is an error, validation should return false
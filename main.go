@@ -1,18 +1,84 @@
 package main
 
 import (
+	"os"
+
 	"fiber-api-example/app/api"
+	authapi "fiber-api-example/app/api/auth"
+	grpcapi "fiber-api-example/app/api/grpc"
 	"fiber-api-example/app/config"
+	"fiber-api-example/app/models/users"
+	"fiber-api-example/app/platform/database"
+	"fiber-api-example/app/platform/events"
+	"fiber-api-example/app/platform/jobs"
+	"fiber-api-example/app/platform/storage"
 	"fiber-api-example/app/server"
 	"fiber-api-example/app/server/middleware"
+	"fiber-api-example/app/utils/auth"
+	"fiber-api-example/app/utils/logger"
 	_ "fiber-api-example/docs"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 )
 
+// @BasePath /api
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
 func main() {
 	config.Init()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		// Run migrate command instead of the server, before migrating on startup.
+		if err := database.RunMigrateCommand(os.Args[2:], os.Stdout); err != nil {
+			logger.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+	db, err := database.New()
+	if err != nil {
+		logger.Fatal("Can't connect to database: ", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		// Run users command instead of the server, e.g. to promote the first admin.
+		defer db.Close()
+		if db.DB() == nil {
+			logger.Fatal("Users command failed: ", "users commands require the postgres driver")
+		}
+		if err := users.RunCommand(db, os.Args[2:], os.Stdout); err != nil {
+			logger.Fatal("Users command failed: ", err)
+		}
+		return
+	}
+	authenticator, err := auth.New(auth.GetConfig())
+	if err != nil {
+		logger.Fatal("Can't configure authentication: ", err)
+	}
+	authenticator.SetUserLookup(authapi.UserLookup(db))
+	blobs, err := storage.New(storage.GetConfig())
+	if err != nil {
+		logger.Fatal("Can't open blob storage: ", err)
+	}
 	app := server.Create()
 	middleware.RegisterMiddlewares(app)
 	api.SwaggerRoute(app)
-	api.SetupRoutes(app)
-	server.StartServerWithGracefulShutdown(app)
+	stream := events.NewChannelPublisher(viper.GetInt("STREAM_BUFFER"))
+	broadcaster := events.NewBroadcaster(stream.Messages(), events.GetBroadcasterConfig())
+	broadcaster.Start()
+	api.SetupRoutes(app, db, authenticator, broadcaster, blobs)
+	grpcServer := server.CreateGRPC(grpc.UnaryInterceptor(grpcapi.UnaryAuthenticator(authenticator)))
+	api.SetupGRPC(grpcServer, db, blobs)
+	if err := grpcServer.Start(); err != nil {
+		logger.Fatal("Can't start gRPC server: ", err)
+	}
+	purger := jobs.NewTrashPurger(db, blobs, viper.GetDuration("TRASH_RETENTION"), viper.GetDuration("TRASH_PURGE_INTERVAL"))
+	purger.Start()
+	idempotencyPurger := jobs.NewIdempotencyPurger(db, viper.GetDuration("IDEMPOTENCY_PURGE_INTERVAL"))
+	idempotencyPurger.Start()
+	dispatcher := jobs.NewWebhookDispatcher(db, jobs.GetWebhookDispatcherConfig())
+	dispatcher.Start()
+	relay := jobs.NewOutboxRelay(db, events.New(events.GetConfig(), dispatcher), jobs.GetOutboxRelayConfig())
+	relay.Start()
+	tailer := jobs.NewOutboxTailer(db, stream, jobs.GetOutboxTailerConfig())
+	tailer.Start()
+	server.StartServerWithGracefulShutdown(app, grpcServer, purger, idempotencyPurger, relay, dispatcher, tailer, broadcaster, db)
 }